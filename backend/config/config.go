@@ -27,6 +27,12 @@ type Config struct {
 	COSBaseURL       string
 	COSKeyPrefix     string
 	COSURLExpires    int
+	ExportTemplate   string
+	CredentialKey    string
+	SheinSyncEnabled bool
+	SheinSyncMinutes int
+	SheinOverlapMins int
+	SheinLookbackHrs int
 }
 
 var AppConfig *Config
@@ -57,6 +63,12 @@ func InitConfig() {
 		COSBaseURL:       getEnv("COS_BASE_URL", ""),
 		COSKeyPrefix:     getEnv("COS_KEY_PREFIX", "orders"),
 		COSURLExpires:    getEnvAsInt("COS_URL_EXPIRES", 3600),
+		ExportTemplate:   getEnv("EXPORT_TEMPLATE_PATH", "template/运营提交表格模板.xlsx"),
+		CredentialKey:    getEnv("CREDENTIAL_ENCRYPT_KEY", ""),
+		SheinSyncEnabled: getEnvAsBool("SHEIN_SYNC_ENABLED", false),
+		SheinSyncMinutes: getEnvAsInt("SHEIN_SYNC_INTERVAL_MINUTES", 15),
+		SheinOverlapMins: getEnvAsInt("SHEIN_SYNC_OVERLAP_MINUTES", 10),
+		SheinLookbackHrs: getEnvAsInt("SHEIN_SYNC_LOOKBACK_HOURS", 24),
 	}
 }
 
@@ -75,3 +87,12 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if v, err := strconv.ParseBool(value); err == nil {
+			return v
+		}
+	}
+	return defaultValue
+}
@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"errors"
+	"fmt"
 	"haodun_manage/backend/database"
 	"haodun_manage/backend/models"
 	"haodun_manage/backend/utils"
+	"math"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,8 +16,8 @@ import (
 type LoginRequest struct {
 	Username    string `json:"username" binding:"required"`
 	Password    string `json:"password" binding:"required"`
-	CaptchaID   string `json:"captcha_id"`   // 验证码暂时隐藏，改为可选
-	CaptchaCode string `json:"captcha_code"` // 验证码暂时隐藏，改为可选
+	CaptchaID   string `json:"captcha_id"`   // 连续登录失败后必填
+	CaptchaCode string `json:"captcha_code"` // 连续登录失败后必填
 }
 
 func getRolePermissionCodes(roleID uint) ([]string, error) {
@@ -37,10 +41,27 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// 验证验证码 - 验证码暂时隐藏，如果提供了验证码才验证
-	if req.CaptchaID != "" && req.CaptchaCode != "" {
+	ip := utils.GetClientIP(c.Request)
+
+	// 用户名或IP处于锁定期内时直接拒绝
+	if remaining := utils.LoginLockRemaining(req.Username, ip); remaining > 0 {
+		utils.LogAction(0, req.Username, "登录失败", "认证", "账号或IP已锁定", ip, c.Request.UserAgent(), 0)
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       fmt.Sprintf("登录失败次数过多，请%d分钟后再试", int(math.Ceil(remaining.Minutes()))),
+			"retry_after": int(math.Ceil(remaining.Seconds())),
+		})
+		return
+	}
+
+	// 验证验证码 - 连续失败后必须提交验证码，其余情况提供了才验证
+	captchaRequired := utils.LoginCaptchaRequired(req.Username, ip)
+	if captchaRequired || (req.CaptchaID != "" && req.CaptchaCode != "") {
+		if req.CaptchaID == "" || req.CaptchaCode == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "请输入验证码", "captcha_required": true})
+			return
+		}
 		if !utils.VerifyCaptcha(req.CaptchaID, req.CaptchaCode) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "验证码错误"})
+			loginFailed(c, req.Username, 0, ip, "验证码错误", "验证码错误")
 			return
 		}
 	}
@@ -51,13 +72,15 @@ func Login(c *gin.Context) {
 		Preload("Role").
 		Preload("Department").
 		First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误1"})
+		// 仍执行一次密码比对，避免通过响应时间判断用户是否存在
+		utils.CheckDummyPassword(req.Password)
+		loginFailed(c, req.Username, 0, ip, "用户不存在", "用户名或密码错误")
 		return
 	}
 
 	// 验证密码
 	if !utils.CheckPasswordHash(req.Password, user.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户名或密码错误2"})
+		loginFailed(c, req.Username, user.ID, ip, "密码错误", "用户名或密码错误")
 		return
 	}
 
@@ -67,15 +90,38 @@ func Login(c *gin.Context) {
 		return
 	}
 
-	// 生成token
-	token, err := utils.GenerateToken(user.ID, user.Username, user.RoleID)
+	// 开启了二次验证或角色要求二次验证时，先返回待完成的挑战；失败计数在第二因素通过后才清除
+	if user.TOTPEnabled || user.Role.RequireMFA {
+		mfaToken, err := utils.CreateMFAChallenge(user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "创建二次验证失败"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_required":       true,
+			"mfa_setup_required": !user.TOTPEnabled,
+			"mfa_token":          mfaToken,
+			"expires_in":         int(utils.MFAChallengeTTL.Seconds()),
+		})
+		return
+	}
+
+	utils.ClearLoginFailures(req.Username)
+	completeLogin(c, &user, nil)
+}
+
+// completeLogin 签发令牌、记录登录日志并返回登录结果，extra 中的字段会合并到响应中
+func completeLogin(c *gin.Context, user *models.User, extra gin.H) {
+	ip := utils.GetClientIP(c.Request)
+
+	// 生成访问令牌与刷新令牌
+	token, refreshToken, err := issueLoginTokens(user, c.Request.UserAgent(), ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
 		return
 	}
 
 	// 记录登录日志
-	ip := utils.GetClientIP(c.Request)
 	utils.LogAction(user.ID, user.Username, "登录", "认证", "用户登录", ip, c.Request.UserAgent(), 1)
 
 	// 获取角色权限与角色信息
@@ -92,17 +138,148 @@ func Login(c *gin.Context) {
 		"permissions":   permissionCodes,
 	}
 
+	mustChangePassword := utils.PasswordChangeRequired(user)
+
+	response := gin.H{
+		"token":                token,
+		"refresh_token":        refreshToken,
+		"expires_in":           int(utils.AccessTokenTTL.Seconds()),
+		"user":                 userResponse,
+		"must_change_password": mustChangePassword,
+	}
+	for key, value := range extra {
+		response[key] = value
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// loginFailed 记录一次失败的登录尝试：累加计数、写日志、递增延迟后返回统一的错误信息
+func loginFailed(c *gin.Context, username string, userID uint, ip, reason, message string) {
+	failures := utils.RecordLoginFailure(username, ip)
+	utils.LogAction(userID, username, "登录失败", "认证", reason, ip, c.Request.UserAgent(), 0)
+
+	if delay := utils.LoginFailureDelay(failures); delay > 0 {
+		time.Sleep(delay)
+	}
+
+	c.JSON(http.StatusUnauthorized, gin.H{
+		"error":            message,
+		"captcha_required": failures >= utils.LoginCaptchaThreshold,
+	})
+}
+
+// UnlockUserLogin 管理员解除用户因多次登录失败造成的锁定
+func UnlockUserLogin(c *gin.Context) {
+	id := c.Param("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if err := utils.UnlockLogin(user.Username); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "解除锁定失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "解除锁定成功"})
+}
+
+// issueLoginTokens 为一次新的登录创建令牌家族及会话，并签发访问令牌和刷新令牌
+func issueLoginTokens(user *models.User, userAgent, ip string) (string, string, error) {
+	familyID, err := utils.NewTokenFamilyID()
+	if err != nil {
+		return "", "", err
+	}
+	token, err := utils.GenerateToken(user.ID, user.Username, user.RoleID, familyID, utils.PasswordChangeRequired(user))
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err := utils.IssueRefreshToken(user.ID, familyID, userAgent, ip)
+	if err != nil {
+		return "", "", err
+	}
+	if err := utils.CreateSession(familyID, user.ID, user.Username, ip, userAgent); err != nil {
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RefreshToken 使用刷新令牌换取新的访问令牌，刷新令牌同时轮换
+func RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userAgent := c.Request.UserAgent()
+	record, err := utils.RotateRefreshToken(req.RefreshToken, userAgent)
+	if err != nil {
+		if errors.Is(err, utils.ErrRefreshTokenInvalid) ||
+			errors.Is(err, utils.ErrRefreshTokenExpired) ||
+			errors.Is(err, utils.ErrRefreshTokenReused) ||
+			errors.Is(err, utils.ErrRefreshTokenMismatch) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "刷新令牌失败"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, record.UserID).Error; err != nil {
+		utils.RevokeTokenFamily(record.FamilyID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "用户不存在"})
+		return
+	}
+	if user.Status != 1 {
+		utils.RevokeTokenFamily(record.FamilyID)
+		c.JSON(http.StatusForbidden, gin.H{"error": "用户已被禁用"})
+		return
+	}
+
+	token, err := utils.GenerateToken(user.ID, user.Username, user.RoleID, record.FamilyID, utils.PasswordChangeRequired(&user))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
+		return
+	}
+	refreshToken, err := utils.IssueRefreshToken(user.ID, record.FamilyID, userAgent, utils.GetClientIP(c.Request))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成token失败"})
+		return
+	}
+	utils.ExtendSession(record.FamilyID, user.ID)
+
 	c.JSON(http.StatusOK, gin.H{
-		"token": token,
-		"user":  userResponse,
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
 	})
 }
 
 func GetCaptcha(c *gin.Context) {
-	captchaID, code := utils.GenerateCaptcha()
+	settings := utils.LoadCaptchaSettings()
+	ip := utils.GetClientIP(c.Request)
+	if !utils.AllowCaptchaRequest(ip, settings.RateLimit) {
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": utils.ErrCaptchaRateLimited.Error()})
+		return
+	}
+
+	captchaID, image, err := utils.GenerateCaptcha(settings)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成验证码失败"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"captcha_id":   captchaID,
-		"captcha_code": code, // 实际生产环境不应该返回验证码，前端应该生成图片
+		"captcha_id":    captchaID,
+		"captcha_image": "data:image/png;base64," + image,
+		"expires_in":    int(settings.TTL.Seconds()),
 	})
 }
 
@@ -129,15 +306,40 @@ func GetCurrentUser(c *gin.Context) {
 		"department_id": user.DepartmentID,
 		"department":    user.Department,
 		"employee_type": user.EmployeeType,
+		"totp_enabled":  user.TOTPEnabled,
 		"permissions":   permissionCodes,
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+// Logout 注销当前令牌及其所属的刷新令牌家族
+func Logout(c *gin.Context) {
+	value, _ := c.Get("token_claims")
+	claims, ok := value.(*utils.Claims)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "未登录"})
+		return
+	}
+
+	if err := utils.RevokeToken(claims); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销失败"})
+		return
+	}
+	if err := utils.RevokeTokenFamily(claims.FamilyID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "注销失败"})
+		return
+	}
+
+	ip := utils.GetClientIP(c.Request)
+	utils.LogAction(claims.UserID, claims.Username, "注销", "认证", "用户注销", ip, c.Request.UserAgent(), 1)
+
+	c.JSON(http.StatusOK, gin.H{"message": "注销成功"})
+}
+
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required"`
 }
 
 func ChangePassword(c *gin.Context) {
@@ -165,6 +367,11 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
+	if err := validateNewPassword(&user, req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 更新密码
 	hashedPassword, err := utils.HashPassword(req.NewPassword)
 	if err != nil {
@@ -172,8 +379,13 @@ func ChangePassword(c *gin.Context) {
 		return
 	}
 
+	now := time.Now()
 	user.Password = hashedPassword
+	user.PasswordChangedAt = &now
+	user.MustChangePassword = false
 	database.DB.Save(&user)
+	utils.RecordPasswordHistory(user.ID, hashedPassword, utils.LoadPasswordPolicy().HistoryCount)
+	utils.RevokeUserTokens(user.ID)
 
 	// 记录操作日志
 	ip := utils.GetClientIP(c.Request)
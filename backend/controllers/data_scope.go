@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
+)
+
+const dataScopeContextKey = "data_scope"
+
+// currentDataScope 解析当前用户的数据范围，同一请求内只解析一次；解析失败时退化为仅本人
+func currentDataScope(c *gin.Context) *utils.DataScope {
+	if value, ok := c.Get(dataScopeContextKey); ok {
+		if scope, ok := value.(*utils.DataScope); ok {
+			return scope
+		}
+	}
+
+	var scope *utils.DataScope
+	if isAdminUser(c) {
+		scope = &utils.DataScope{All: true}
+	} else {
+		resolved, err := utils.ResolveDataScope(currentUserID(c))
+		if err != nil {
+			log.Printf("解析数据范围失败 user=%d: %v", currentUserID(c), err)
+			resolved = &utils.DataScope{CreatorIDs: []uint64{uint64(currentUserID(c))}}
+		}
+		scope = resolved
+	}
+
+	c.Set(dataScopeContextKey, scope)
+	return scope
+}
+
+// applyDataScope 按当前用户的数据范围限制查询的 created_by
+func applyDataScope(c *gin.Context, query *gorm.DB) *gorm.DB {
+	scope := currentDataScope(c)
+	if scope.All {
+		return query
+	}
+	return query.Where("created_by IN ?", scope.CreatorIDs)
+}
+
+// canAccessCreatedBy 判断指定创建人的数据是否在当前用户的数据范围内
+func canAccessCreatedBy(c *gin.Context, createdBy uint64) bool {
+	return currentDataScope(c).Allows(createdBy)
+}
+
+// ensureOrderAccessible 校验订单存在且在当前用户的数据范围内，失败时直接写回响应
+func ensureOrderAccessible(c *gin.Context, orderID uint64) bool {
+	var order models.OrderInfo
+	if err := database.DB.Select("id", "created_by").First(&order, orderID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "订单不存在"})
+		return false
+	}
+	if !canAccessCreatedBy(c, order.CreatedBy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该订单"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
+)
+
+const (
+	// importJobErrorLimit 查询任务时最多返回的错误明细条数，完整内容见错误报告
+	importJobErrorLimit = 500
+	// importJobHeartbeatInterval 执行中的任务更新心跳的间隔，超过 importJobStaleAfter 未更新的任务视为已中断
+	importJobHeartbeatInterval = 30 * time.Second
+	importJobStaleAfter        = 3 * time.Minute
+)
+
+// importErrorItem 对外返回的单条导入错误
+type importErrorItem struct {
+	Sheet   string `json:"sheet"`
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func newImportErrorItems(errs []validationError) []importErrorItem {
+	items := make([]importErrorItem, 0, len(errs))
+	for _, e := range errs {
+		items = append(items, importErrorItem{
+			Sheet:   e.Sheet,
+			Row:     e.Row,
+			Field:   e.Field,
+			Code:    e.Code,
+			Message: e.message(),
+		})
+	}
+	return items
+}
+
+// createImportJob 以等待执行状态保存导入任务，任务归属当前实例
+func createImportJob(job models.ImportJob) (*models.ImportJob, error) {
+	now := time.Now()
+	job.Status = models.ImportJobPending
+	job.Owner = utils.InstanceID
+	job.HeartbeatAt = &now
+	if err := database.DB.Create(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// keepImportJobAlive 定时更新任务心跳，返回的函数用于在任务结束时停止
+func keepImportJobAlive(jobID uint64) func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(importJobHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				updateImportJob(jobID, map[string]interface{}{"heartbeat_at": time.Now()})
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
+
+// StartImportJobMonitor 启动时及之后定期将心跳超时的未完成任务标记为失败，
+// 执行这些任务的实例已退出；其他实例仍在执行的任务不受影响
+func StartImportJobMonitor() {
+	failStaleImportJobs()
+	go func() {
+		ticker := time.NewTicker(importJobStaleAfter)
+		defer ticker.Stop()
+		for range ticker.C {
+			failStaleImportJobs()
+		}
+	}()
+}
+
+func failStaleImportJobs() {
+	now := time.Now()
+	result := database.DB.Model(&models.ImportJob{}).
+		Where("status IN ?", []string{models.ImportJobPending, models.ImportJobRunning}).
+		Where("heartbeat_at IS NULL OR heartbeat_at < ?", now.Add(-importJobStaleAfter)).
+		Updates(map[string]interface{}{"status": models.ImportJobFailed, "message": "执行任务的服务实例已停止，任务已中断", "finished_at": now})
+	if result.Error != nil {
+		log.Printf("fail stale import jobs failed: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("marked %d stale import jobs as failed", result.RowsAffected)
+	}
+}
+
+func updateImportJob(jobID uint64, updates map[string]interface{}) {
+	if err := database.DB.Model(&models.ImportJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
+		log.Printf("update import job %d failed: %v", jobID, err)
+	}
+}
+
+// finishImportJob 结束任务并记录结果，errs 为校验错误明细
+func finishImportJob(jobID uint64, status, message string, errs []validationError, updates map[string]interface{}) {
+	if updates == nil {
+		updates = make(map[string]interface{})
+	}
+	updates["status"] = status
+	updates["message"] = message
+	updates["finished_at"] = time.Now()
+	if len(errs) > 0 {
+		if encoded, err := json.Marshal(newImportErrorItems(errs)); err == nil {
+			updates["errors"] = string(encoded)
+		}
+		updates["error_count"] = len(errs)
+	}
+	updateImportJob(jobID, updates)
+}
+
+// saveImportJobResult 保存任务结果（如预检明细）
+func saveImportJobResult(jobID uint64, result interface{}) {
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		log.Printf("encode import job %d result failed: %v", jobID, err)
+		return
+	}
+	updateImportJob(jobID, map[string]interface{}{"result": string(encoded)})
+}
+
+// requestValue 读取表单参数，未提供时读取查询参数
+func requestValue(c *gin.Context, key string) string {
+	value := strings.TrimSpace(c.PostForm(key))
+	if value == "" {
+		value = strings.TrimSpace(c.Query(key))
+	}
+	return value
+}
+
+// requestBool 读取表单或查询参数中的布尔开关，支持 true/1/yes/on
+func requestBool(c *gin.Context, key string) bool {
+	switch strings.ToLower(requestValue(c, key)) {
+	case "1", "true", "yes", "on":
+		return true
+	default:
+		return false
+	}
+}
+
+// saveImportErrorReport 将错误报告写入本地存储，返回存储路径
+func saveImportErrorReport(jobID uint64, content []byte) (string, error) {
+	objectKey := path.Join("import_reports", fmt.Sprintf("job_%d_%d.xlsx", jobID, time.Now().Unix()))
+	fullPath, err := utils.GetLocalFilePath(objectKey)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(fullPath, content, 0o644); err != nil {
+		return "", err
+	}
+	return objectKey, nil
+}
+
+// loadImportJob 读取导入任务，仅任务创建人与超级管理员可访问
+func loadImportJob(c *gin.Context) (*models.ImportJob, bool) {
+	jobID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "任务ID格式错误"})
+		return nil, false
+	}
+
+	var job models.ImportJob
+	if err := database.DB.First(&job, jobID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "导入任务不存在"})
+		return nil, false
+	}
+	if job.CreatedBy != currentUserID(c) && !isAdminUser(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权查看该导入任务"})
+		return nil, false
+	}
+	return &job, true
+}
+
+// GetImportJob 查询导入任务进度与结果
+func GetImportJob(c *gin.Context) {
+	job, ok := loadImportJob(c)
+	if !ok {
+		return
+	}
+
+	progress := 0
+	switch {
+	case job.IsFinished():
+		progress = 100
+	case job.TotalRows > 0:
+		progress = job.ProcessedRows * 100 / job.TotalRows
+	}
+
+	errs := make([]importErrorItem, 0)
+	if job.Errors != "" {
+		if err := json.Unmarshal([]byte(job.Errors), &errs); err != nil {
+			log.Printf("decode import job %d errors failed: %v", job.ID, err)
+		}
+	}
+	truncated := len(errs) > importJobErrorLimit
+	if truncated {
+		errs = errs[:importJobErrorLimit]
+	}
+
+	var result interface{}
+	if job.Result != "" {
+		result = json.RawMessage(job.Result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":             job,
+		"progress":         progress,
+		"errors":           errs,
+		"errors_truncated": truncated,
+		"has_error_report": job.ErrorReportPath != "",
+		"result":           result,
+	})
+}
+
+// DownloadImportJobReport 下载标注了错误信息的导入文件
+func DownloadImportJobReport(c *gin.Context) {
+	job, ok := loadImportJob(c)
+	if !ok {
+		return
+	}
+	if job.ErrorReportPath == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "该任务没有错误报告"})
+		return
+	}
+
+	fullPath, err := utils.GetLocalFilePath(job.ErrorReportPath)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "定位错误报告失败"})
+		return
+	}
+	if _, err := os.Stat(fullPath); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "错误报告已失效"})
+		return
+	}
+
+	c.FileAttachment(fullPath, fmt.Sprintf("import_errors_%d%s", job.ID, filepath.Ext(fullPath)))
+}
@@ -0,0 +1,534 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+// headerNotFoundHint 工作表中没有可识别的表头
+const headerNotFoundHint = "未找到有效的表头，请确认模板是否正确"
+
+// orderImportField 可通过导入模板映射的订单字段
+type orderImportField struct {
+	Key    string // OrderInfo 的 JSON 名
+	Label  string // 内置模板中的表头
+	column func(idx *columnIndex) *int
+}
+
+var orderImportFields = []orderImportField{
+	{"gsp_order_no", "GSP订单号", func(idx *columnIndex) *int { return &idx.GSPOrderNo }},
+	{"order_created_at", "订单创建时间", func(idx *columnIndex) *int { return &idx.OrderCreatedAt }},
+	{"required_sign_at", "要求签收时间", func(idx *columnIndex) *int { return &idx.RequiredSignAt }},
+	{"shipping_warehouse_code", "发货仓库", func(idx *columnIndex) *int { return &idx.ShippingWarehouse }},
+	{"shop_code", "店铺编号", func(idx *columnIndex) *int { return &idx.ShopCode }},
+	{"owner_name", "负责人", func(idx *columnIndex) *int { return &idx.OwnerName }},
+	{"product_id", "商品ID", func(idx *columnIndex) *int { return &idx.ProductID }},
+	{"product_name", "商品名称", func(idx *columnIndex) *int { return &idx.ProductName }},
+	{"spec", "规格", func(idx *columnIndex) *int { return &idx.Spec }},
+	{"item_no", "货号", func(idx *columnIndex) *int { return &idx.ItemNo }},
+	{"seller_sku", "卖家SKU", func(idx *columnIndex) *int { return &idx.SellerSKU }},
+	{"platform_sku", "平台SKU", func(idx *columnIndex) *int { return &idx.PlatformSKU }},
+	{"platform_skc", "平台SKC", func(idx *columnIndex) *int { return &idx.PlatformSKC }},
+	{"platform_spu", "平台SPU", func(idx *columnIndex) *int { return &idx.PlatformSPU }},
+	{"product_price", "商品价格", func(idx *columnIndex) *int { return &idx.ProductPrice }},
+	{"expected_revenue", "商品预计收入", func(idx *columnIndex) *int { return &idx.ExpectedRevenue }},
+	{"special_product_note", "特殊产品备注", func(idx *columnIndex) *int { return &idx.SpecialNote }},
+	{"expected_fulfillment_qty", "应履约件数", func(idx *columnIndex) *int { return &idx.ExpectedQty }},
+	{"currency_code", "币种", func(idx *columnIndex) *int { return &idx.CurrencyCode }},
+	{"postal_code", "邮编", func(idx *columnIndex) *int { return &idx.PostalCode }},
+	{"country", "国家", func(idx *columnIndex) *int { return &idx.Country }},
+	{"province", "省份", func(idx *columnIndex) *int { return &idx.Province }},
+	{"city", "城市", func(idx *columnIndex) *int { return &idx.City }},
+	{"district", "区", func(idx *columnIndex) *int { return &idx.District }},
+	{"address_line1", "用户地址1", func(idx *columnIndex) *int { return &idx.AddressLine1 }},
+	{"address_line2", "用户地址2", func(idx *columnIndex) *int { return &idx.AddressLine2 }},
+	{"customer_full_name", "用户全称", func(idx *columnIndex) *int { return &idx.CustomerFullName }},
+	{"customer_last_name", "用户姓氏", func(idx *columnIndex) *int { return &idx.CustomerLastName }},
+	{"customer_first_name", "用户名字", func(idx *columnIndex) *int { return &idx.CustomerFirstName }},
+	{"phone_number", "手机号", func(idx *columnIndex) *int { return &idx.PhoneNumber }},
+	{"email", "用户邮箱", func(idx *columnIndex) *int { return &idx.Email }},
+	{"tax_number", "税号", func(idx *columnIndex) *int { return &idx.TaxNumber }},
+	{"payment_time", "支付时间", func(idx *columnIndex) *int { return &idx.PaymentTime }},
+	{"completed_at", "完成时间", func(idx *columnIndex) *int { return &idx.CompletedAt }},
+}
+
+// builtinSheetKeywords 内置的工作表名称关键词
+var builtinSheetKeywords = map[string][]string{
+	"platform": {"平台面单", "platform"},
+	"factory":  {"工厂物流", "factory"},
+}
+
+func findOrderImportField(key string) (orderImportField, bool) {
+	for _, field := range orderImportFields {
+		if field.Key == key {
+			return field, true
+		}
+	}
+	return orderImportField{}, false
+}
+
+// normalizeHeaderTitle 统一表头写法：去除空格与常见标点，全角括号转半角
+func normalizeHeaderTitle(header string) string {
+	title := strings.TrimSpace(header)
+	replacements := []struct{ old, new string }{
+		{"（", "("},
+		{"）", ")"},
+		{"[", "("},
+		{"]", ")"},
+		{"，", ""},
+		{",", ""},
+		{"。", ""},
+		{"/", ""},
+		{"\\", ""},
+		{"-", ""},
+		{"_", ""},
+		{":", ""},
+		{"：", ""},
+		{"\n", ""},
+		{" ", ""},
+	}
+	for _, rep := range replacements {
+		title = strings.ReplaceAll(title, rep.old, rep.new)
+	}
+	return title
+}
+
+// applyImportAliases 按模板中的表头别名定位列，优先于内置关键词识别结果
+func applyImportAliases(idx *columnIndex, headers []string, mapping *models.ImportSheetMapping) {
+	if mapping == nil {
+		return
+	}
+	normalized := make([]string, len(headers))
+	for i, header := range headers {
+		normalized[i] = strings.ToLower(normalizeHeaderTitle(header))
+	}
+
+	for _, column := range mapping.Columns {
+		field, ok := findOrderImportField(column.Field)
+		if !ok {
+			continue
+		}
+		position := -1
+		for _, alias := range column.Aliases {
+			target := strings.ToLower(normalizeHeaderTitle(alias))
+			if target == "" {
+				continue
+			}
+			for i, title := range normalized {
+				if title == target {
+					position = i
+					break
+				}
+			}
+			if position >= 0 {
+				break
+			}
+		}
+		if position >= 0 {
+			assignImportColumn(idx, field, position)
+		}
+	}
+}
+
+// applyFieldKeyHeaders 表头与订单字段名（如 gsp_order_no）一致时直接对应该字段，
+// 便于导入由 JSON Lines 或脚本生成的文件
+func applyFieldKeyHeaders(idx *columnIndex, headers []string) {
+	for i, header := range headers {
+		title := strings.ToLower(normalizeHeaderTitle(header))
+		if title == "" {
+			continue
+		}
+		for _, field := range orderImportFields {
+			if title == normalizeHeaderTitle(field.Key) {
+				assignImportColumn(idx, field, i)
+				break
+			}
+		}
+	}
+}
+
+// assignImportColumn 将列明确映射到字段，并取消内置关键词对其他字段的误判
+func assignImportColumn(idx *columnIndex, field orderImportField, position int) {
+	for _, other := range orderImportFields {
+		if ptr := other.column(idx); other.Key != field.Key && *ptr == position {
+			*ptr = -1
+		}
+	}
+	*field.column(idx) = position
+}
+
+// missingImportColumns 返回缺少的必需列，模板中配置了默认值的列不视为缺少
+func missingImportColumns(idx columnIndex, orderType string, mapping *models.ImportSheetMapping) []string {
+	missing := missingRequiredColumns(idx, orderType)
+	if mapping == nil {
+		return missing
+	}
+
+	withDefault := make(map[string]bool)
+	var required []string
+	for _, column := range mapping.Columns {
+		field, ok := findOrderImportField(column.Field)
+		if !ok {
+			continue
+		}
+		if column.Default != "" {
+			withDefault[field.Label] = true
+			continue
+		}
+		if column.Required && *field.column(&idx) < 0 {
+			required = append(required, field.Label)
+		}
+	}
+
+	result := make([]string, 0, len(missing)+len(required))
+	seen := make(map[string]bool)
+	for _, name := range append(missing, required...) {
+		if withDefault[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// applyImportDefaults 为配置了默认值的字段分配列，缺少的列追加在 width 之后；返回列号到默认值的映射
+func applyImportDefaults(idx *columnIndex, width int, mapping *models.ImportSheetMapping) map[int]string {
+	if mapping == nil {
+		return nil
+	}
+	defaults := make(map[int]string)
+	for _, column := range mapping.Columns {
+		field, ok := findOrderImportField(column.Field)
+		if !ok || column.Default == "" {
+			continue
+		}
+		ptr := field.column(idx)
+		if *ptr < 0 {
+			*ptr = width
+			width++
+		}
+		defaults[*ptr] = column.Default
+	}
+	return defaults
+}
+
+// fillImportDefaults 用默认值填充空单元格
+func fillImportDefaults(row []string, defaults map[int]string) []string {
+	if len(defaults) == 0 {
+		return row
+	}
+	width := len(row)
+	for column := range defaults {
+		if column >= width {
+			width = column + 1
+		}
+	}
+	filled := make([]string, width)
+	copy(filled, row)
+	for column, value := range defaults {
+		if strings.TrimSpace(filled[column]) == "" {
+			filled[column] = value
+		}
+	}
+	return filled
+}
+
+// checkImportRequired 校验模板中标记为必填的字段
+func checkImportRequired(errs *[]validationError, row []string, idx columnIndex, mapping *models.ImportSheetMapping, rowNum int, sheetName string) {
+	if mapping == nil {
+		return
+	}
+	for _, column := range mapping.Columns {
+		field, ok := findOrderImportField(column.Field)
+		if !ok || !column.Required || getValue(row, *field.column(&idx)) != "" {
+			continue
+		}
+		reported := false
+		for _, e := range *errs {
+			if e.Field == field.Label && e.Code == "required" {
+				reported = true
+				break
+			}
+		}
+		if !reported {
+			appendValidationError(errs, sheetName, rowNum, field.Label, "required", "")
+		}
+	}
+}
+
+// builtinImportProfile 以导入模板的形式描述内置识别规则，便于前端展示
+func builtinImportProfile() models.ImportProfile {
+	profile := models.ImportProfile{
+		Name:        "内置默认",
+		Description: "按内置关键词识别表头，适用于运营提交表格模板",
+		Status:      1,
+		BuiltIn:     true,
+	}
+	for _, orderType := range []string{"platform", "factory"} {
+		required := make(map[string]bool)
+		for _, name := range missingRequiredColumns(newColumnIndex(), orderType) {
+			required[name] = true
+		}
+		sheet := models.ImportSheetMapping{
+			OrderType:     orderType,
+			SheetKeywords: builtinSheetKeywords[orderType],
+		}
+		for _, field := range orderImportFields {
+			sheet.Columns = append(sheet.Columns, models.ImportColumnMapping{
+				Field:    field.Key,
+				Aliases:  []string{field.Label},
+				Required: required[field.Label],
+			})
+		}
+		profile.Sheets = append(profile.Sheets, sheet)
+	}
+	return profile
+}
+
+// loadImportProfile 读取导入时选择的模板，id 为 0 表示使用内置规则
+func loadImportProfile(id uint64) (*models.ImportProfile, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	var profile models.ImportProfile
+	if err := database.DB.Where("id = ? AND status = 1", id).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("导入模板不存在或已停用")
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// normalizeImportProfile 清理并校验模板内容
+func normalizeImportProfile(profile *models.ImportProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	profile.ShopCode = strings.TrimSpace(profile.ShopCode)
+	if profile.Name == "" {
+		return errors.New("模板名称不能为空")
+	}
+	if len(profile.Sheets) == 0 {
+		return errors.New("请至少配置一个工作表")
+	}
+
+	seenTypes := make(map[string]bool)
+	for i := range profile.Sheets {
+		sheet := &profile.Sheets[i]
+		sheet.OrderType = strings.ToLower(strings.TrimSpace(sheet.OrderType))
+		if _, ok := builtinSheetKeywords[sheet.OrderType]; !ok {
+			return fmt.Errorf("不支持的订单类型: %s", sheet.OrderType)
+		}
+		if seenTypes[sheet.OrderType] {
+			return fmt.Errorf("订单类型 %s 重复配置", sheet.OrderType)
+		}
+		seenTypes[sheet.OrderType] = true
+		sheet.SheetKeywords = compactStrings(sheet.SheetKeywords)
+
+		seenFields := make(map[string]bool)
+		for j := range sheet.Columns {
+			column := &sheet.Columns[j]
+			column.Field = strings.TrimSpace(column.Field)
+			if _, ok := findOrderImportField(column.Field); !ok {
+				return fmt.Errorf("不支持的订单字段: %s", column.Field)
+			}
+			if seenFields[column.Field] {
+				return fmt.Errorf("字段 %s 重复配置", column.Field)
+			}
+			seenFields[column.Field] = true
+			column.Aliases = compactStrings(column.Aliases)
+			column.Default = strings.TrimSpace(column.Default)
+		}
+	}
+	return nil
+}
+
+func compactStrings(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func importFieldOptions() []gin.H {
+	options := make([]gin.H, 0, len(orderImportFields))
+	for _, field := range orderImportFields {
+		options = append(options, gin.H{"value": field.Key, "label": field.Label})
+	}
+	return options
+}
+
+// ListImportProfiles 获取导入模板列表，首项为内置默认模板
+func ListImportProfiles(c *gin.Context) {
+	query := database.DB.Model(&models.ImportProfile{})
+	if shopCode := strings.TrimSpace(c.Query("shop_code")); shopCode != "" {
+		query = query.Where("shop_code = ? OR shop_code = ''", shopCode)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var profiles []models.ImportProfile
+	if err := query.Order("id DESC").Find(&profiles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取导入模板失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   append([]models.ImportProfile{builtinImportProfile()}, profiles...),
+		"fields": importFieldOptions(),
+	})
+}
+
+// GetImportProfile 获取导入模板详情，id 为 0 时返回内置默认模板
+func GetImportProfile(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "模板ID格式错误"})
+		return
+	}
+	if id == 0 {
+		c.JSON(http.StatusOK, gin.H{"data": builtinImportProfile()})
+		return
+	}
+
+	var profile models.ImportProfile
+	if err := database.DB.First(&profile, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "导入模板不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+// purgeDeletedImportProfiles 清除同名的已软删除模板，避免其占用名称唯一索引
+func purgeDeletedImportProfiles(name string) error {
+	return database.DB.Unscoped().
+		Where("name = ? AND deleted_at IS NOT NULL", name).
+		Delete(&models.ImportProfile{}).Error
+}
+
+// importProfileRequest 创建或更新导入模板的请求；创建时未传 status 默认启用，更新时未传则保持原状态
+type importProfileRequest struct {
+	models.ImportProfile
+	Status *int `json:"status"`
+}
+
+// CreateImportProfile 创建导入模板
+func CreateImportProfile(c *gin.Context) {
+	var req importProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	profile := req.ImportProfile
+	if err := normalizeImportProfile(&profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.ImportProfile{}).Where("name = ?", profile.Name).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "模板名称已存在"})
+		return
+	}
+	if err := purgeDeletedImportProfiles(profile.Name); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建导入模板失败"})
+		return
+	}
+
+	profile.ID = 0
+	profile.CreatedBy = currentUserID(c)
+	profile.Status = 1
+	disabled := req.Status != nil && *req.Status == 0
+	// status 字段带默认值，零值插入时会被替换为 1，停用的模板需在创建后单独更新
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&profile).Error; err != nil {
+			return err
+		}
+		if !disabled {
+			return nil
+		}
+		profile.Status = 0
+		return tx.Model(&profile).Update("status", 0).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建导入模板失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+// UpdateImportProfile 更新导入模板
+func UpdateImportProfile(c *gin.Context) {
+	var profile models.ImportProfile
+	if err := database.DB.First(&profile, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "导入模板不存在"})
+		return
+	}
+
+	var req importProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := normalizeImportProfile(&req.ImportProfile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != profile.Name {
+		var count int64
+		database.DB.Model(&models.ImportProfile{}).Where("name = ? AND id != ?", req.Name, profile.ID).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "模板名称已存在"})
+			return
+		}
+		if err := purgeDeletedImportProfiles(req.Name); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新导入模板失败"})
+			return
+		}
+	}
+
+	profile.Name = req.Name
+	profile.ShopCode = req.ShopCode
+	profile.Description = req.Description
+	if req.Status != nil {
+		profile.Status = 0
+		if *req.Status != 0 {
+			profile.Status = 1
+		}
+	}
+	profile.Sheets = req.Sheets
+	if err := database.DB.Save(&profile).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新导入模板失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+// DeleteImportProfile 删除导入模板，直接物理删除以便重新使用模板名称
+func DeleteImportProfile(c *gin.Context) {
+	result := database.DB.Unscoped().Delete(&models.ImportProfile{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除导入模板失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "导入模板不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
@@ -295,9 +295,7 @@ func ListMaterials(c *gin.Context) {
 	if filters.Format != "" {
 		query = query.Where("format = ?", filters.Format)
 	}
-	if !isAdminUser(c) {
-		query = query.Where("created_by = ?", currentUserID(c))
-	}
+	query = applyDataScope(c, query)
 
 	var total int64
 	if err := query.Count(&total).Error; err != nil {
@@ -998,8 +996,5 @@ func buildMaterialFolderTree(folders []models.MaterialFolder) []models.MaterialF
 }
 
 func canAccessMaterial(c *gin.Context, material models.MaterialAsset) bool {
-	if isAdminUser(c) {
-		return true
-	}
-	return material.CreatedBy == uint64(c.GetUint("user_id"))
+	return canAccessCreatedBy(c, material.CreatedBy)
 }
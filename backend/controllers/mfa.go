@@ -0,0 +1,402 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
+)
+
+const (
+	mfaRecoveryCodeCount = 10
+	mfaDefaultIssuer     = "haodun_manage"
+)
+
+type mfaChallengeRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code"`
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type disableMFARequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+func mfaIssuer() string {
+	if name := strings.TrimSpace(getConfigValue("site_name")); name != "" {
+		return name
+	}
+	return mfaDefaultIssuer
+}
+
+// startTOTPEnrollment 生成待确认的 TOTP 密钥并返回认证器所需信息
+func startTOTPEnrollment(c *gin.Context, user *models.User) {
+	if !utils.CredentialKeyConfigured() {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "未配置 CREDENTIAL_ENCRYPT_KEY，无法开启二次验证"})
+		return
+	}
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成密钥失败"})
+		return
+	}
+	if err := utils.SavePendingTOTPSecret(user.ID, secret); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "生成密钥失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": utils.TOTPProvisioningURI(mfaIssuer(), user.Username, secret),
+		"expires_in":  int(utils.MFAEnrollTTL.Seconds()),
+	})
+}
+
+// activateTOTP 用动态口令确认待启用的密钥，成功后保存并返回一组新的恢复码
+func activateTOTP(user *models.User, code string) ([]string, bool, error) {
+	secret := utils.GetPendingTOTPSecret(user.ID)
+	if secret == "" {
+		return nil, false, nil
+	}
+	step, ok := utils.MatchTOTPStep(secret, code)
+	if !ok || !utils.AcceptTOTPStep(user.ID, step) {
+		return nil, false, nil
+	}
+
+	codes, hashes, err := utils.GenerateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		return nil, false, err
+	}
+	encoded, _ := json.Marshal(hashes)
+	encrypted, err := utils.EncryptSecret(secret)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := database.DB.Model(user).Updates(map[string]interface{}{
+		"totp_secret":         encrypted,
+		"totp_enabled":        true,
+		"totp_recovery_codes": string(encoded),
+	}).Error; err != nil {
+		return nil, false, err
+	}
+
+	utils.ClearPendingTOTPSecret(user.ID)
+	return codes, true, nil
+}
+
+// userTOTPSecret 解密用户保存的 TOTP 密钥，旧版本明文保存的密钥原样返回
+func userTOTPSecret(user *models.User) (string, bool) {
+	if secret, err := utils.DecryptSecret(user.TOTPSecret); err == nil {
+		return secret, true
+	}
+	if utils.IsTOTPSecret(user.TOTPSecret) {
+		return user.TOTPSecret, true
+	}
+	return "", false
+}
+
+// verifyUserSecondFactor 校验动态口令，失败时尝试作为恢复码使用（恢复码一次有效）。
+// 同一动态口令只能使用一次，已使用过的口令视为错误
+func verifyUserSecondFactor(user *models.User, code string) bool {
+	if !user.TOTPEnabled {
+		return false
+	}
+	if secret, ok := userTOTPSecret(user); ok {
+		if step, ok := utils.MatchTOTPStep(secret, code); ok {
+			return utils.AcceptTOTPStep(user.ID, step)
+		}
+	}
+
+	var hashes []string
+	if user.TOTPRecoveryCodes == "" || json.Unmarshal([]byte(user.TOTPRecoveryCodes), &hashes) != nil {
+		return false
+	}
+	target := utils.HashRecoveryCode(code)
+	for i, hash := range hashes {
+		if hash != target {
+			continue
+		}
+		remaining := append(hashes[:i:i], hashes[i+1:]...)
+		encoded, _ := json.Marshal(remaining)
+		// 仅当恢复码未被并发请求修改时才消耗，保证同一恢复码只能成功使用一次
+		result := database.DB.Model(&models.User{}).
+			Where("id = ? AND totp_recovery_codes = ?", user.ID, user.TOTPRecoveryCodes).
+			Update("totp_recovery_codes", string(encoded))
+		if result.Error != nil || result.RowsAffected == 0 {
+			return false
+		}
+		user.TOTPRecoveryCodes = string(encoded)
+		return true
+	}
+	return false
+}
+
+func loadMFAChallengeUser(c *gin.Context, mfaToken string) (*models.User, bool) {
+	userID, err := utils.GetMFAChallengeUser(mfaToken)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Role").Preload("Department").First(&user, userID).Error; err != nil {
+		utils.ConsumeMFAChallenge(mfaToken)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": utils.ErrMFAChallengeInvalid.Error()})
+		return nil, false
+	}
+	if user.Status != 1 {
+		utils.ConsumeMFAChallenge(mfaToken)
+		c.JSON(http.StatusForbidden, gin.H{"error": "用户已被禁用"})
+		return nil, false
+	}
+	return &user, true
+}
+
+// SetupMFAChallenge 角色要求二次验证但用户尚未绑定时，凭登录挑战获取待绑定的密钥
+func SetupMFAChallenge(c *gin.Context) {
+	var req mfaChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, ok := loadMFAChallengeUser(c, req.MFAToken)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "已开启二次验证"})
+		return
+	}
+
+	startTOTPEnrollment(c, user)
+}
+
+// VerifyMFAChallenge 完成登录二次验证并签发正式令牌
+func VerifyMFAChallenge(c *gin.Context) {
+	var req mfaChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请输入验证码"})
+		return
+	}
+
+	user, ok := loadMFAChallengeUser(c, req.MFAToken)
+	if !ok {
+		return
+	}
+
+	// 二次验证失败计入账号锁定，账号锁定期间不再接受验证码
+	ip := utils.GetClientIP(c.Request)
+	if remaining := utils.LoginLockRemaining(user.Username, ip); remaining > 0 {
+		utils.ConsumeMFAChallenge(req.MFAToken)
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":       fmt.Sprintf("登录失败次数过多，请%d分钟后再试", int(math.Ceil(remaining.Minutes()))),
+			"retry_after": int(math.Ceil(remaining.Seconds())),
+		})
+		return
+	}
+
+	var extra gin.H
+	if user.TOTPEnabled {
+		if !verifyUserSecondFactor(user, req.Code) {
+			mfaFailed(c, user, req.MFAToken, ip, "二次验证码错误")
+			return
+		}
+	} else {
+		codes, activated, err := activateTOTP(user, req.Code)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "开启二次验证失败"})
+			return
+		}
+		if !activated {
+			mfaFailed(c, user, req.MFAToken, ip, "二次验证绑定失败")
+			return
+		}
+		extra = gin.H{"recovery_codes": codes}
+	}
+
+	// 第二因素通过后才清除密码阶段累积的失败次数
+	utils.ClearLoginFailures(user.Username)
+	utils.ConsumeMFAChallenge(req.MFAToken)
+	completeLogin(c, user, extra)
+}
+
+// mfaFailed 记录一次二次验证失败：同时累加挑战与账号的失败次数，账号被锁定时挑战随之作废
+func mfaFailed(c *gin.Context, user *models.User, mfaToken, ip, reason string) {
+	utils.RecordMFAChallengeFailure(mfaToken)
+	failures := utils.RecordLoginFailure(user.Username, ip)
+	if failures >= utils.LoginUserLockThreshold {
+		utils.ConsumeMFAChallenge(mfaToken)
+	}
+	utils.LogAction(user.ID, user.Username, "登录失败", "认证", reason, ip, c.Request.UserAgent(), 0)
+
+	if delay := utils.LoginFailureDelay(failures); delay > 0 {
+		time.Sleep(delay)
+	}
+	c.JSON(http.StatusUnauthorized, gin.H{"error": "二次验证码错误"})
+}
+
+// EnrollMFA 为当前用户生成待确认的 TOTP 密钥
+func EnrollMFA(c *gin.Context) {
+	var user models.User
+	if err := database.DB.First(&user, currentUserID(c)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "已开启二次验证"})
+		return
+	}
+
+	startTOTPEnrollment(c, &user)
+}
+
+// EnableMFA 校验动态口令后为当前用户开启二次验证
+func EnableMFA(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, currentUserID(c)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "已开启二次验证"})
+		return
+	}
+
+	codes, activated, err := activateTOTP(&user, req.Code)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "开启二次验证失败"})
+		return
+	}
+	if !activated {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "验证码错误或密钥已过期"})
+		return
+	}
+
+	ip := utils.GetClientIP(c.Request)
+	utils.LogAction(user.ID, user.Username, "开启二次验证", "认证", "用户开启二次验证", ip, c.Request.UserAgent(), 1)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "二次验证已开启",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableMFA 当前用户关闭二次验证，需要同时提供密码与动态口令
+func DisableMFA(c *gin.Context) {
+	var req disableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.Preload("Role").First(&user, currentUserID(c)).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+	if !user.TOTPEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "未开启二次验证"})
+		return
+	}
+	if user.Role.RequireMFA {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "当前角色要求开启二次验证，无法关闭"})
+		return
+	}
+	if !utils.CheckPasswordHash(req.Password, user.Password) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "密码错误"})
+		return
+	}
+	if !verifyUserSecondFactor(&user, req.Code) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "二次验证码错误"})
+		return
+	}
+
+	if err := clearUserMFA(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "关闭二次验证失败"})
+		return
+	}
+
+	ip := utils.GetClientIP(c.Request)
+	utils.LogAction(user.ID, user.Username, "关闭二次验证", "认证", "用户关闭二次验证", ip, c.Request.UserAgent(), 1)
+
+	c.JSON(http.StatusOK, gin.H{"message": "二次验证已关闭"})
+}
+
+// ResetUserMFA 管理员重置用户的二次验证（如丢失设备），并使其现有令牌失效
+func ResetUserMFA(c *gin.Context) {
+	id := c.Param("id")
+	var user models.User
+	if err := database.DB.First(&user, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "用户不存在"})
+		return
+	}
+
+	if err := clearUserMFA(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "重置二次验证失败"})
+		return
+	}
+	utils.RevokeUserTokens(user.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "二次验证已重置"})
+}
+
+func clearUserMFA(user *models.User) error {
+	utils.ClearPendingTOTPSecret(user.ID)
+	return database.DB.Model(user).Updates(map[string]interface{}{
+		"totp_secret":         "",
+		"totp_enabled":        false,
+		"totp_recovery_codes": "",
+	}).Error
+}
+
+// EncryptLegacyTOTPSecrets 将旧版本明文保存的 TOTP 密钥加密保存，未配置加密密钥时跳过
+func EncryptLegacyTOTPSecrets() {
+	if !utils.CredentialKeyConfigured() {
+		return
+	}
+	var users []models.User
+	if err := database.DB.Select("id", "totp_secret").Where("totp_secret <> ''").Find(&users).Error; err != nil {
+		log.Printf("failed to load totp secrets: %v", err)
+		return
+	}
+	for _, user := range users {
+		if !utils.IsTOTPSecret(user.TOTPSecret) {
+			continue
+		}
+		encrypted, err := utils.EncryptSecret(user.TOTPSecret)
+		if err != nil {
+			log.Printf("failed to encrypt totp secret of user %d: %v", user.ID, err)
+			return
+		}
+		if err := database.DB.Model(&models.User{}).
+			Where("id = ? AND totp_secret = ?", user.ID, user.TOTPSecret).
+			Update("totp_secret", encrypted).Error; err != nil {
+			log.Printf("failed to encrypt totp secret of user %d: %v", user.ID, err)
+		}
+	}
+}
@@ -33,20 +33,15 @@ var (
 	chinesePattern = regexp.MustCompile(`^[\p{Han}]+$`)
 )
 
-const adminRoleID uint = 1
-
+// isAdminUser 当前用户的角色是否为超级管理员，由认证中间件解析后写入上下文
 func isAdminUser(c *gin.Context) bool {
-	return c.GetUint("role_id") == adminRoleID
+	return c.GetBool("is_super_admin")
 }
 
 func currentUserID(c *gin.Context) uint {
 	return c.GetUint("user_id")
 }
 
-func isCompletedStatus(value int8) bool {
-	return value == 1
-}
-
 // saveOrderParams 包含创建/更新订单时的所有字段
 type saveOrderParams struct {
 	GSPOrderNo             string   `json:"gsp_order_no"`
@@ -100,10 +95,13 @@ func CreateOrder(c *gin.Context) {
 		return
 	}
 
-	if params.Status != nil && params.CompletedAt == nil && isCompletedStatus(*params.Status) {
-		now := time.Now().Format("2006-01-02 15:04:05")
-		params.CompletedAt = &now
+	// 新订单一律为待处理，之后的状态变化须经状态流转并记录
+	if params.Status != nil && *params.Status != models.OrderStatusPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "新建订单的状态只能为待处理，请创建后通过状态流转修改"})
+		return
 	}
+	params.Status = nil
+	params.CompletedAt = nil
 
 	order := models.OrderInfo{}
 	assignOrderFields(&order, &params)
@@ -119,7 +117,14 @@ func CreateOrder(c *gin.Context) {
 }
 
 func UpdateOrder(c *gin.Context) {
-	id := c.Param("id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "订单ID格式错误"})
+		return
+	}
+	if !ensureOrderAccessible(c, id) {
+		return
+	}
 
 	var order models.OrderInfo
 	if err := database.DB.First(&order, id).Error; err != nil {
@@ -138,28 +143,49 @@ func UpdateOrder(c *gin.Context) {
 		return
 	}
 
-	if params.Status != nil {
-		log.Printf("[DEBUG] UpdateOrder - Status: %d, isCompletedStatus: %v, CompletedAt before: %v",
-			*params.Status, isCompletedStatus(*params.Status), params.CompletedAt)
-		if isCompletedStatus(*params.Status) && params.CompletedAt == nil {
-			now := time.Now().Format("2006-01-02 15:04:05")
-			params.CompletedAt = &now
-			log.Printf("[DEBUG] UpdateOrder - Set CompletedAt to current time: %s", now)
-		} else if !isCompletedStatus(*params.Status) {
-			empty := ""
-			params.CompletedAt = &empty
-			log.Printf("[DEBUG] UpdateOrder - Set CompletedAt to empty string (will be cleared)")
-		}
-		log.Printf("[DEBUG] UpdateOrder - CompletedAt after: %v", params.CompletedAt)
+	// 状态与完成时间由状态机维护，状态变化时按流转规则校验并记录，且需具备状态流转接口的权限
+	var targetStatus *int8
+	if params.Status != nil && *params.Status != order.Status {
+		if !isAdminUser(c) {
+			allowed, err := utils.RoleCanAccessResource(c.GetUint("role_id"), http.MethodPost, orderTransitionPath)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "权限校验失败"})
+				return
+			}
+			if !allowed {
+				c.JSON(http.StatusForbidden, gin.H{"error": "无权变更订单状态"})
+				return
+			}
+		}
+		targetStatus = params.Status
 	}
+	params.Status = nil
+	params.CompletedAt = nil
 	assignOrderFields(&order, &params)
 	order.UpdatedBy = uint64(c.GetUint("user_id"))
 
-	if err := database.DB.Model(&models.OrderInfo{}).
-		Where("id = ?", order.ID).
-		Select("*").
-		Updates(&order).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新订单失败"})
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.OrderInfo{}).
+			Where("id = ?", order.ID).
+			Select("*").
+			Updates(&order).Error; err != nil {
+			return err
+		}
+		if targetStatus != nil {
+			return transitionOrderStatus(tx, &order, *targetStatus, currentUserID(c), c.GetString("username"), "编辑订单")
+		}
+		return nil
+	})
+	if err != nil {
+		var transitionErr *orderTransitionError
+		switch {
+		case errors.As(err, &transitionErr):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, errOrderStatusChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新订单失败"})
+		}
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": order})
@@ -171,6 +197,9 @@ func DeleteOrder(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "订单ID格式错误"})
 		return
 	}
+	if !ensureOrderAccessible(c, id) {
+		return
+	}
 
 	var order models.OrderInfo
 	if err := database.DB.Where("id = ?", id).First(&order).Error; err != nil {
@@ -184,41 +213,49 @@ func DeleteOrder(c *gin.Context) {
 		return
 	}
 
-	var attachments []models.OrderAttachment
-	if err := tx.Where("order_id = ?", order.ID).Find(&attachments).Error; err != nil {
+	attachments, message, err := deleteOrderWithAttachments(tx, &order)
+	if err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取订单附件失败"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
 		return
 	}
 
-	for _, att := range attachments {
-		if err := utils.DeleteAttachment(c.Request.Context(), att.Storage, att.FilePath); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "删除附件文件失败"})
-			return
-		}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交事务失败"})
+		return
+	}
+	deleteOrderAttachmentFiles(c.Request.Context(), attachments)
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// deleteOrderWithAttachments 在事务内删除订单的附件记录及订单本身，返回被删除的附件，
+// 附件文件须在事务提交成功后通过 deleteOrderAttachmentFiles 删除；失败时返回对应步骤的提示
+func deleteOrderWithAttachments(tx *gorm.DB, order *models.OrderInfo) ([]models.OrderAttachment, string, error) {
+	var attachments []models.OrderAttachment
+	if err := tx.Where("order_id = ?", order.ID).Find(&attachments).Error; err != nil {
+		return nil, "获取订单附件失败", err
 	}
 
 	if len(attachments) > 0 {
 		if err := tx.Unscoped().Where("order_id = ?", order.ID).Delete(&models.OrderAttachment{}).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "删除附件记录失败"})
-			return
+			return nil, "删除附件记录失败", err
 		}
 	}
 
-	if err := tx.Unscoped().Delete(&order).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除订单失败"})
-		return
+	if err := tx.Unscoped().Delete(order).Error; err != nil {
+		return nil, "删除订单失败", err
 	}
+	return attachments, "", nil
+}
 
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "提交事务失败"})
-		return
+// deleteOrderAttachmentFiles 删除已提交删除的附件文件，记录已不存在，失败时仅记录日志
+func deleteOrderAttachmentFiles(ctx context.Context, attachments []models.OrderAttachment) {
+	for _, att := range attachments {
+		if err := utils.DeleteAttachment(ctx, att.Storage, att.FilePath); err != nil {
+			log.Printf("failed to delete attachment file %s (%s): %v", att.FilePath, att.Storage, err)
+		}
 	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
 func BatchUploadOrderAttachments(c *gin.Context) {
@@ -303,6 +340,9 @@ func processBatchAttachment(c *gin.Context, fileHeader *multipart.FileHeader, st
 		}
 		return nil, fmt.Errorf("文件 %s: 查询订单失败: %v", fileName, lookupErr)
 	}
+	if !canAccessCreatedBy(c, order.CreatedBy) {
+		return nil, fmt.Errorf("文件 %s: 无权访问匹配的订单（%s）", fileName, baseName)
+	}
 
 	src, err := fileHeader.Open()
 	if err != nil {
@@ -412,11 +452,6 @@ func ListOrders(c *gin.Context) {
 
 	query = applyOrderFilters(c, query)
 
-	if !isAdminUser(c) {
-		userID := currentUserID(c)
-		query = query.Where("created_by = ?", userID)
-	}
-
 	page := parsePositiveInt(c.DefaultQuery("page", "1"), 1)
 	pageSize := parsePositiveInt(c.DefaultQuery("page_size", "10"), 10)
 	if pageSize > 200 {
@@ -627,7 +662,7 @@ func applyOrderFilters(c *gin.Context, query *gorm.DB) *gorm.DB {
 		query = query.Where(fmt.Sprintf("%s LIKE ?", fuzzyField), likeValue)
 	}
 
-	return query
+	return applyDataScope(c, query)
 }
 
 func parseQueryTime(value string) (time.Time, error) {
@@ -651,6 +686,7 @@ func parsePositiveInt(value string, defaultValue int) int {
 	return defaultValue
 }
 
+// ImportOrders 上传订单文件并创建后台导入任务，进度通过 GET /api/import-jobs/:id 查询
 func ImportOrders(c *gin.Context) {
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
@@ -671,116 +707,86 @@ func ImportOrders(c *gin.Context) {
 		return
 	}
 
-	sheets, err := parseWorkbook(data)
+	format, err := detectOrderFileFormat(requestValue(c, "format"), fileHeader.Filename)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("无法解析Excel文件: %v", err)})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	defaultAddress := getConfigValue("default_address")
-	if defaultAddress == "" {
-		defaultAddress = config.AppConfig.LocalBaseURL
+	orderType := "platform"
+	if value := requestValue(c, "order_type"); value != "" {
+		if orderType = normalizeImportOrderType(value); orderType == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "order_type 仅支持 platform 或 factory"})
+			return
+		}
 	}
 
-	shippingSet := loadShippingWarehouseSet()
-
-	platformOrders, platformErrs := parsePlatformSheet(sheets, defaultAddress, shippingSet)
-	factoryOrders, factoryErrs := parseFactorySheet(sheets, defaultAddress, shippingSet)
-
-	validationErrors := append(platformErrs, factoryErrs...)
-
-	orders := append(platformOrders, factoryOrders...)
-	if len(orders) == 0 && len(validationErrors) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Excel 中未找到可导入的数据"})
-		return
+	onError := strings.ToLower(requestValue(c, "on_error"))
+	if onError == "" {
+		onError = models.ImportOnErrorAbort
 	}
-
-	if formatted := formatValidationErrors(validationErrors); len(formatted) > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": strings.Join(formatted, "; ")})
+	if onError != models.ImportOnErrorAbort && onError != models.ImportOnErrorSkip {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "on_error 仅支持 abort 或 skip"})
 		return
 	}
 
-	tx := database.DB.Begin()
-	operatorID := c.GetUint("user_id")
-	userID := uint64(operatorID)
-	for i := range orders {
-		orders[i].UpdatedBy = userID
-		if orders[i].CreatedBy == 0 {
-			orders[i].CreatedBy = userID
-		}
-		if err := upsertOrder(tx, &orders[i]); err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("导入失败: %v", err)})
+	var profileID uint64
+	if value := requestValue(c, "profile_id"); value != "" {
+		if profileID, err = strconv.ParseUint(value, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "导入模板ID格式错误"})
 			return
 		}
-		if err := autoLinkMaterialForOrder(c.Request.Context(), tx, &orders[i], operatorID); err != nil {
-			log.Printf("auto link material failed for order %s: %v", orders[i].GSPOrderNo, err)
-		} else {
-			log.Printf("auto link material success for order %s", orders[i].GSPOrderNo)
-		}
 	}
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("导入失败: %v", err)})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("成功导入%d条订单", len(orders))})
-}
-
-func ExportOrders(c *gin.Context) {
-	templatePath := filepath.Join("template", "运营提交表格模板.xlsx")
-	f, err := excelize.OpenFile(templatePath)
+	profile, err := loadImportProfile(profileID)
 	if err != nil {
-		log.Printf("failed to open template: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出失败，模板不可用"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	defer func() { _ = f.Close() }()
 
-	type sheetConfig struct {
-		SheetName string
-		OrderType string
-		Writer    func(*excelize.File, string, int, models.OrderInfo)
+	opts := orderImportOptions{
+		OperatorID: currentUserID(c),
+		Format:     format,
+		OrderType:  orderType,
+		DryRun:     requestBool(c, "dry_run"),
+		OnError:    onError,
+		Profile:    profile,
 	}
-	sheets := []sheetConfig{
-		{SheetName: "平台面单", OrderType: "platform", Writer: writePlatformRow},
-		{SheetName: "工厂物流", OrderType: "factory", Writer: writeFactoryRow},
+	job, err := startOrderImportJob(fileHeader.Filename, data, opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建导入任务失败"})
+		return
 	}
 
-	for _, sheet := range sheets {
-		if err := clearSheetData(f, sheet.SheetName); err != nil {
-			log.Printf("failed to clear sheet %s: %v", sheet.SheetName, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "导出失败"})
-			return
-		}
-
-		orders, err := queryOrdersForExport(c, sheet.OrderType)
-		if err != nil {
-			log.Printf("failed to query %s orders: %v", sheet.OrderType, err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "导出失败"})
-			return
-		}
-
-		for i, order := range orders {
-			rowNum := i + 2 // 数据从第二行开始
-			sheet.Writer(f, sheet.SheetName, rowNum, order)
-		}
+	message := "导入任务已创建"
+	if opts.DryRun {
+		message = "预检任务已创建"
 	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": message,
+		"job_id":  job.ID,
+		"status":  job.Status,
+		"dry_run": job.DryRun,
+	})
+}
 
-	buffer, err := f.WriteToBuffer()
+// ExportOrders 按列表筛选条件导出订单，columns 指定导出列；format 为 csv 或 jsonl 时以文本格式流式导出，
+// 默认流式生成 Excel，template=1 时基于导出模板生成
+func ExportOrders(c *gin.Context) {
+	columns, err := parseOrderExportColumns(c.Query("columns"))
 	if err != nil {
-		log.Printf("failed to write workbook to buffer: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出失败"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	filename := fmt.Sprintf("orders_all_%d.xlsx", time.Now().Unix())
-	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
-	if _, err := c.Writer.Write(buffer.Bytes()); err != nil {
-		log.Printf("failed to send workbook: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "导出失败"})
-		return
+	switch strings.ToLower(c.DefaultQuery("format", orderFileFormatXLSX)) {
+	case orderFileFormatXLSX:
+		exportOrdersXLSX(c, columns)
+	case orderFileFormatCSV:
+		exportOrdersCSV(c, columns)
+	case orderFileFormatJSONL, "ndjson":
+		exportOrdersJSONLines(c, columns)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format 仅支持 xlsx、csv 或 jsonl"})
 	}
 }
 
@@ -891,26 +897,7 @@ func detectColumns(headers []string) columnIndex {
 		if title == "" || strings.HasPrefix(title, "#") {
 			continue
 		}
-		replacements := []struct{ old, new string }{
-			{"（", "("},
-			{"）", ")"},
-			{"[", "("},
-			{"]", ")"},
-			{"，", ""},
-			{",", ""},
-			{"。", ""},
-			{"/", ""},
-			{"\\", ""},
-			{"-", ""},
-			{"_", ""},
-			{":", ""},
-			{"：", ""},
-			{"\n", ""},
-			{" ", ""},
-		}
-		for _, rep := range replacements {
-			title = strings.ReplaceAll(title, rep.old, rep.new)
-		}
+		title = normalizeHeaderTitle(title)
 		lower := strings.ToLower(title)
 
 		set := func(current *int, value int) {
@@ -1179,19 +1166,21 @@ func getSheetRows(sheets workbookSheets, keywords []string) ([][]string, string,
 	return nil, "", fmt.Errorf("%w: 未找到匹配的工作表, 期望关键词: %s", errSheetNotFound, strings.Join(keywords, ","))
 }
 
-func findHeaderRow(rows [][]string, orderType string) (int, columnIndex, []string) {
+func findHeaderRow(rows [][]string, orderType string, mapping *models.ImportSheetMapping) (int, columnIndex, []string) {
 	for i, row := range rows {
 		idx := detectColumns(row)
+		applyFieldKeyHeaders(&idx, row)
+		applyImportAliases(&idx, row, mapping)
 		if !looksLikeHeader(idx) {
 			continue
 		}
-		missing := missingRequiredColumns(idx, orderType)
+		missing := missingImportColumns(idx, orderType, mapping)
 		if len(missing) > 0 {
-			return -1, columnIndex{}, missing
+			return -1, idx, missing
 		}
 		return i, idx, nil
 	}
-	return -1, columnIndex{}, []string{"未找到有效的表头，请确认模板是否正确"}
+	return -1, newColumnIndex(), []string{headerNotFoundHint}
 }
 
 func looksLikeHeader(idx columnIndex) bool {
@@ -1211,117 +1200,112 @@ func looksLikeHeader(idx columnIndex) bool {
 	return positiveCount >= 3
 }
 
-func parsePlatformSheet(sheets workbookSheets, defaultAddress string, shippingSet map[string]struct{}) ([]models.OrderInfo, []validationError) {
-	rows, sheetName, err := getSheetRows(sheets, []string{"平台面单", "platform"})
-	if err != nil {
-		if errors.Is(err, errSheetNotFound) {
-			return nil, nil
-		}
-		return nil, []validationError{{Sheet: "平台面单", Row: 1, Field: "文件", Code: "custom", Extra: err.Error()}}
-	}
-	if len(rows) == 0 {
-		return []models.OrderInfo{}, nil
-	}
+// importRow 通过校验的订单行
+type importRow struct {
+	Sheet     string
+	Row       int // Excel 行号
+	Order     models.OrderInfo
+	Duplicate bool // 系统中已有类似记录
+}
 
-	headerRow, idx, missing := findHeaderRow(rows, "platform")
-	if headerRow < 0 {
-		applyPlatformColumnDefaults(&idx)
-		missing = missingRequiredColumns(idx, "platform")
-		if len(missing) > 0 {
-			errs := make([]validationError, len(missing))
-			for idxMissing, name := range missing {
-				errs[idxMissing] = validationError{Sheet: sheetName, Row: headerRow + 1, Field: name, Code: "custom", Extra: "缺少列"}
-			}
-			return nil, errs
-		}
-		headerRow = 0
-	} else {
-		applyPlatformColumnDefaults(&idx)
-	}
+// sheetParseResult 单个工作表的解析结果
+type sheetParseResult struct {
+	Sheet     string
+	HeaderRow int // 表头所在的 Excel 行号
+	Rows      []importRow
+	Errors    []validationError
+}
 
-	var orders []models.OrderInfo
-	var validationErrors []validationError
-	var duplicateRows []int
+// parsePlatformSheet 解析平台面单，mapping 为 nil 时按内置规则识别表头
+func parsePlatformSheet(sheets workbookSheets, defaultAddress string, shippingSet map[string]struct{}, mapping *models.ImportSheetMapping) sheetParseResult {
+	return parseOrderSheet(sheets, "平台面单", "platform", defaultAddress, shippingSet, mapping,
+		applyPlatformColumnDefaults, platformOrderExists)
+}
 
-	for i := headerRow + 1; i < len(rows); i++ {
-		row := rows[i]
-		if rowIsEmpty(row) {
-			continue
-		}
-		excelRowNum := i + 1
-		order, rowErrors := buildOrderFromRow(row, idx, defaultAddress, shippingSet, excelRowNum, sheetName, "platform")
-		if len(rowErrors) > 0 {
-			validationErrors = append(validationErrors, rowErrors...)
-			continue
-		}
-		exists, err := platformOrderExists(order)
-		if err != nil {
-			validationErrors = append(validationErrors, validationError{
-				Sheet: sheetName,
-				Row:   excelRowNum,
-				Field: "记录",
-				Code:  "duplicate_check",
-				Extra: fmt.Sprintf("检查重复时出错: %v", err),
-			})
-			continue
-		}
-		if exists {
-			duplicateRows = append(duplicateRows, excelRowNum)
-		}
-		orders = append(orders, order)
-	}
-	if len(duplicateRows) > 0 {
-		addDuplicateRowsWarning(&validationErrors, sheetName, duplicateRows)
-	}
-	return orders, validationErrors
+// parseFactorySheet 解析工厂物流，mapping 为 nil 时按内置规则识别表头
+func parseFactorySheet(sheets workbookSheets, defaultAddress string, shippingSet map[string]struct{}, mapping *models.ImportSheetMapping) sheetParseResult {
+	return parseOrderSheet(sheets, "工厂物流", "factory", defaultAddress, shippingSet, mapping,
+		applyFactoryColumnDefaults, factoryOrderExists)
 }
 
-func parseFactorySheet(sheets workbookSheets, defaultAddress string, shippingSet map[string]struct{}) ([]models.OrderInfo, []validationError) {
-	rows, sheetName, err := getSheetRows(sheets, []string{"工厂物流", "factory"})
+func parseOrderSheet(
+	sheets workbookSheets,
+	defaultSheetName, orderType, defaultAddress string,
+	shippingSet map[string]struct{},
+	mapping *models.ImportSheetMapping,
+	applyDefaults func(*columnIndex),
+	exists func(models.OrderInfo) (bool, error),
+) sheetParseResult {
+	result := sheetParseResult{Sheet: defaultSheetName, HeaderRow: 1}
+
+	keywords := builtinSheetKeywords[orderType]
+	if mapping != nil && len(mapping.SheetKeywords) > 0 {
+		keywords = mapping.SheetKeywords
+	}
+	rows, sheetName, err := getSheetRows(sheets, keywords)
 	if err != nil {
-		if errors.Is(err, errSheetNotFound) {
-			return nil, nil
+		if !errors.Is(err, errSheetNotFound) {
+			result.Errors = []validationError{{Sheet: defaultSheetName, Row: 1, Field: "文件", Code: "custom", Extra: err.Error()}}
 		}
-		return nil, []validationError{{Sheet: "工厂物流", Row: 1, Field: "文件", Code: "custom", Extra: err.Error()}}
+		return result
 	}
+	result.Sheet = sheetName
 	if len(rows) == 0 {
-		return []models.OrderInfo{}, nil
+		return result
 	}
 
-	headerRow, idx, missing := findHeaderRow(rows, "factory")
+	headerRow, idx, missing := findHeaderRow(rows, orderType, mapping)
+	if headerRow < 0 && mapping != nil {
+		// 使用导入模板时不再按固定列位置兜底
+		for _, name := range missing {
+			if name == headerNotFoundHint {
+				result.Errors = append(result.Errors, validationError{Sheet: sheetName, Row: 1, Field: "表头", Code: "custom", Extra: "未识别，请确认导入模板是否正确"})
+				continue
+			}
+			result.Errors = append(result.Errors, validationError{Sheet: sheetName, Row: 1, Field: name, Code: "custom", Extra: "缺少列"})
+		}
+		return result
+	}
 	if headerRow < 0 {
-		applyFactoryColumnDefaults(&idx)
-		missing = missingRequiredColumns(idx, "factory")
+		applyDefaults(&idx)
+		missing = missingRequiredColumns(idx, orderType)
 		if len(missing) > 0 {
-			errs := make([]validationError, len(missing))
-			for idxMissing, name := range missing {
-				errs[idxMissing] = validationError{Sheet: sheetName, Row: headerRow + 1, Field: name, Code: "custom", Extra: "缺少列"}
+			for _, name := range missing {
+				result.Errors = append(result.Errors, validationError{Sheet: sheetName, Row: 1, Field: name, Code: "custom", Extra: "缺少列"})
 			}
-			return nil, errs
+			return result
 		}
 		headerRow = 0
-	} else {
-		applyFactoryColumnDefaults(&idx)
+	} else if mapping == nil {
+		applyDefaults(&idx)
 	}
+	result.HeaderRow = headerRow + 1
 
-	var orders []models.OrderInfo
-	var validationErrors []validationError
-	var duplicateRows []int
+	width := 0
+	for _, row := range rows {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	defaults := applyImportDefaults(&idx, width, mapping)
 
+	var duplicateRows []int
 	for i := headerRow + 1; i < len(rows); i++ {
 		row := rows[i]
 		if rowIsEmpty(row) {
 			continue
 		}
 		excelRowNum := i + 1
-		order, rowErrors := buildOrderFromRow(row, idx, defaultAddress, shippingSet, excelRowNum, sheetName, "factory")
+		row = fillImportDefaults(row, defaults)
+		order, rowErrors := buildOrderFromRow(row, idx, defaultAddress, shippingSet, excelRowNum, sheetName, orderType)
+		checkImportRequired(&rowErrors, row, idx, mapping, excelRowNum, sheetName)
 		if len(rowErrors) > 0 {
-			validationErrors = append(validationErrors, rowErrors...)
+			result.Errors = append(result.Errors, rowErrors...)
 			continue
 		}
-		exists, err := factoryOrderExists(order)
+		duplicate, err := exists(order)
 		if err != nil {
-			validationErrors = append(validationErrors, validationError{
+			result.Errors = append(result.Errors, validationError{
 				Sheet: sheetName,
 				Row:   excelRowNum,
 				Field: "记录",
@@ -1330,15 +1314,15 @@ func parseFactorySheet(sheets workbookSheets, defaultAddress string, shippingSet
 			})
 			continue
 		}
-		if exists {
+		if duplicate {
 			duplicateRows = append(duplicateRows, excelRowNum)
 		}
-		orders = append(orders, order)
+		result.Rows = append(result.Rows, importRow{Sheet: sheetName, Row: excelRowNum, Order: order, Duplicate: duplicate})
 	}
 	if len(duplicateRows) > 0 {
-		addDuplicateRowsWarning(&validationErrors, sheetName, duplicateRows)
+		addDuplicateRowsWarning(&result.Errors, sheetName, duplicateRows)
 	}
-	return orders, validationErrors
+	return result
 }
 
 func platformOrderExists(order models.OrderInfo) (bool, error) {
@@ -1380,22 +1364,17 @@ func factoryOrderExists(order models.OrderInfo) (bool, error) {
 	return count > 0, nil
 }
 
+// addDuplicateRowsWarning 为系统中已有类似记录的每一行追加提示
 func addDuplicateRowsWarning(errors *[]validationError, sheetName string, rows []int) {
-	if len(rows) == 0 {
-		return
+	for _, row := range rows {
+		*errors = append(*errors, validationError{
+			Sheet: sheetName,
+			Row:   row,
+			Field: "记录",
+			Code:  "duplicate",
+			Extra: "已有类似记录，请检查!",
+		})
 	}
-	rowTexts := make([]string, len(rows))
-	for i, row := range rows {
-		rowTexts[i] = strconv.Itoa(row)
-	}
-	message := fmt.Sprintf("%s[第%s行]已有类似记录，请检查!", sheetName, strings.Join(rowTexts, ","))
-	*errors = append(*errors, validationError{
-		Sheet: sheetName,
-		Row:   rows[0],
-		Field: "记录",
-		Code:  "duplicate",
-		Extra: message,
-	})
 }
 
 func buildOrderFromRow(row []string, idx columnIndex, defaultAddress string, shippingSet map[string]struct{}, rowNum int, sheetName, orderType string) (models.OrderInfo, []validationError) {
@@ -1624,144 +1603,6 @@ func buildOrderFromRow(row []string, idx columnIndex, defaultAddress string, shi
 	return order, errors
 }
 
-func writePlatformHeader(f *excelize.File, sheet string) {
-	headers := []string{
-		"GSP订单号", "发货仓库", "店铺编号", "负责人", "商品名称", "规格", "货号", "卖家SKU", "平台SKU", "平台SKC", "平台SPU",
-		"商品价格", "特殊产品备注", "应履约件数", "邮编", "国家", "省份", "城市", "区", "用户地址1", "用户地址2",
-		"用户全称", "用户姓氏", "用户名字", "手机号", "用户邮箱", "税号",
-	}
-	for i, value := range headers {
-		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
-		f.SetCellValue(sheet, cell, value)
-	}
-}
-
-func writeFactoryHeader(f *excelize.File, sheet string) {
-	headers := []string{
-		"GSP订单号", "订单创建时间", "店铺编号", "负责人", "商品名称", "规格", "货号", "卖家SKU", "平台SKU", "平台SKC", "平台SPU",
-		"商品价格", "特殊产品备注", "应履约件数", "邮编", "国家", "省份", "城市", "区", "用户地址1", "用户地址2",
-		"用户全称", "用户姓氏", "用户名字", "手机号", "用户邮箱", "税号",
-	}
-	for i, value := range headers {
-		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
-		f.SetCellValue(sheet, cell, value)
-	}
-}
-
-func clearSheetData(f *excelize.File, sheet string) error {
-	rows, err := f.GetRows(sheet)
-	if err != nil {
-		return err
-	}
-	for row := len(rows); row >= 2; row-- {
-		if err := f.RemoveRow(sheet, row); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
-func queryOrdersForExport(c *gin.Context, orderType string) ([]models.OrderInfo, error) {
-	query := database.DB.Model(&models.OrderInfo{}).Where("order_type = ?", orderType)
-	query = applyOrderFilters(c, query)
-	if !isAdminUser(c) {
-		userID := currentUserID(c)
-		query = query.Where("created_by = ?", userID)
-	}
-
-	var orders []models.OrderInfo
-	if err := query.Order("id DESC").Find(&orders).Error; err != nil {
-		return nil, err
-	}
-	return orders, nil
-}
-
-func writePlatformRow(f *excelize.File, sheet string, row int, order models.OrderInfo) {
-	priceValue := ""
-	if order.ProductPrice != 0 {
-		priceValue = fmt.Sprintf("%g", order.ProductPrice)
-	}
-
-	values := []interface{}{
-		order.GSPOrderNo,
-		order.ShippingWarehouseCode,
-		order.ShopCode,
-		order.OwnerName,
-		order.ProductName,
-		order.Spec,
-		order.ItemNo,
-		order.SellerSKU,
-		order.PlatformSKU,
-		order.PlatformSKC,
-		order.PlatformSPU,
-		priceValue,
-		order.SpecialProductNote,
-		order.ExpectedFulfillmentQty,
-		order.PostalCode,
-		order.Country,
-		order.Province,
-		order.City,
-		order.District,
-		order.AddressLine1,
-		order.AddressLine2,
-		order.CustomerFullName,
-		order.CustomerLastName,
-		order.CustomerFirstName,
-		order.PhoneNumber,
-		order.Email,
-		order.TaxNumber,
-	}
-	for i, value := range values {
-		cell, _ := excelize.CoordinatesToCellName(i+1, row)
-		f.SetCellValue(sheet, cell, value)
-	}
-}
-
-func writeFactoryRow(f *excelize.File, sheet string, row int, order models.OrderInfo) {
-	createdAt := ""
-	if !order.OrderCreatedAt.IsZero() {
-		createdAt = order.OrderCreatedAt.Format("2006-01-02 15:04:05")
-	}
-	priceValue := ""
-	if order.ProductPrice != 0 {
-		priceValue = fmt.Sprintf("%g", order.ProductPrice)
-	}
-
-	values := []interface{}{
-		order.GSPOrderNo,
-		createdAt,
-		order.ShopCode,
-		order.OwnerName,
-		order.ProductName,
-		order.Spec,
-		order.ItemNo,
-		order.SellerSKU,
-		order.PlatformSKU,
-		order.PlatformSKC,
-		order.PlatformSPU,
-		priceValue,
-		order.SpecialProductNote,
-		order.CurrencyCode,
-		order.PostalCode,
-		order.Country,
-		order.Province,
-		order.City,
-		order.District,
-		order.AddressLine1,
-		order.AddressLine2,
-		order.CustomerFullName,
-		order.CustomerLastName,
-		order.CustomerFirstName,
-		order.PhoneNumber,
-		order.Email,
-		order.TaxNumber,
-	}
-	for i, value := range values {
-		cell, _ := excelize.CoordinatesToCellName(i+1, row)
-		f.SetCellValue(sheet, cell, value)
-	}
-}
-
 func getValue(row []string, idx int) string {
 	if idx < 0 || idx >= len(row) {
 		return ""
@@ -1795,35 +1636,52 @@ func rowIsEmpty(row []string) bool {
 }
 
 func upsertOrder(tx *gorm.DB, order *models.OrderInfo) error {
+	normalizeUpsertOrder(order)
+
+	existing, err := findUpsertTarget(tx, order)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OrderInfo{}).
+			Where("id = ?", order.ID).
+			UpdateColumn("updated_at", gorm.Expr("NULL")).Error; err != nil {
+			return err
+		}
+		order.UpdatedAt = time.Time{}
+		return nil
+	}
+
+	order.ID = existing.ID
+	order.CreatedAt = existing.CreatedAt
+	order.CreatedBy = existing.CreatedBy
+	return tx.Model(existing).Updates(order).Error
+}
+
+// normalizeUpsertOrder 补全写入前的默认值
+func normalizeUpsertOrder(order *models.OrderInfo) {
 	if order.OrderCreatedAt.IsZero() {
 		order.OrderCreatedAt = time.Now()
 	}
 	if order.ItemCount == 0 {
 		order.ItemCount = 1
 	}
+}
 
+// findUpsertTarget 查找 upsertOrder 将要更新的已有订单，不存在时返回 nil
+func findUpsertTarget(db *gorm.DB, order *models.OrderInfo) (*models.OrderInfo, error) {
 	var existing models.OrderInfo
-	err := tx.Where("gsp_order_no = ? AND order_type = ? AND order_created_at = ?", order.GSPOrderNo, order.OrderType, order.OrderCreatedAt).First(&existing).Error
+	err := db.Where("gsp_order_no = ? AND order_type = ? AND order_created_at = ?", order.GSPOrderNo, order.OrderType, order.OrderCreatedAt).First(&existing).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			if err := tx.Create(order).Error; err != nil {
-				return err
-			}
-			if err := tx.Model(&models.OrderInfo{}).
-				Where("id = ?", order.ID).
-				UpdateColumn("updated_at", gorm.Expr("NULL")).Error; err != nil {
-				return err
-			}
-			order.UpdatedAt = time.Time{}
-			return nil
+			return nil, nil
 		}
-		return err
+		return nil, err
 	}
-
-	order.ID = existing.ID
-	order.CreatedAt = existing.CreatedAt
-	order.CreatedBy = existing.CreatedBy
-	return tx.Model(&existing).Updates(order).Error
+	return &existing, nil
 }
 
 type orderResponse struct {
@@ -1933,6 +1791,30 @@ func autoLinkMaterialForOrder(ctx context.Context, tx *gorm.DB, order *models.Or
 		return nil
 	}
 
+	db := database.DB
+	if tx != nil {
+		db = tx
+	}
+
+	material, matchKey, err := findAutoLinkMaterial(db, order)
+	if err != nil {
+		log.Printf("autoLinkMaterialForOrder: query material failed for key=%s orderID=%d err=%v", matchKey, order.ID, err)
+		return err
+	}
+	if material == nil {
+		log.Printf("autoLinkMaterialForOrder: no material found for key=%s orderID=%d", matchKey, order.ID)
+		return nil
+	}
+	log.Printf("autoLinkMaterialForOrder: matched material=%d for key=%s orderID=%d", material.ID, matchKey, order.ID)
+
+	if _, err := upsertOrderMaterialAttachment(ctx, db, order.ID, "material_image", material, operatorID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// findAutoLinkMaterial 查找订单可自动关联的素材，未匹配时返回 nil，同时返回用于匹配的关键字
+func findAutoLinkMaterial(db *gorm.DB, order *models.OrderInfo) (*models.MaterialAsset, string, error) {
 	orderType := strings.ToLower(strings.TrimSpace(order.OrderType))
 	//平台订单，产品素材图文件名需与表格中“订单号”完全一致；
 	matchKey := strings.TrimSpace(order.GSPOrderNo)
@@ -1941,12 +1823,7 @@ func autoLinkMaterialForOrder(ctx context.Context, tx *gorm.DB, order *models.Or
 		matchKey = strings.TrimSpace(order.ItemNo)
 	}
 	if matchKey == "" {
-		return nil
-	}
-
-	db := database.DB
-	if tx != nil {
-		db = tx
+		return nil, matchKey, nil
 	}
 
 	lowerKey := strings.ToLower(matchKey)
@@ -1954,18 +1831,11 @@ func autoLinkMaterialForOrder(ctx context.Context, tx *gorm.DB, order *models.Or
 	if err := db.Where("LOWER(TRIM(title)) = ?", lowerKey).Or("LOWER(TRIM(file_name)) LIKE ?", lowerKey+".%").
 		First(&material).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			log.Printf("autoLinkMaterialForOrder: no material found for key=%s orderID=%d", matchKey, order.ID)
-			return nil
+			return nil, matchKey, nil
 		}
-		log.Printf("autoLinkMaterialForOrder: query material failed for key=%s orderID=%d err=%v", matchKey, order.ID, err)
-		return err
+		return nil, matchKey, err
 	}
-	log.Printf("autoLinkMaterialForOrder: matched material=%d for key=%s orderID=%d", material.ID, matchKey, order.ID)
-
-	if _, err := upsertOrderMaterialAttachment(ctx, db, order.ID, "material_image", &material, operatorID); err != nil {
-		return err
-	}
-	return nil
+	return &material, matchKey, nil
 }
 
 func validateOrderParams(params *saveOrderParams) error {
@@ -2137,6 +2007,8 @@ func validateOrderParams(params *saveOrderParams) error {
 
 	if params.Status == nil {
 		addErr("状态", "required", "")
+	} else if !models.IsValidOrderStatus(*params.Status) {
+		addErr("状态", "custom", " 不是有效的订单状态")
 	}
 
 	params.CurrencyCode = strings.TrimSpace(params.CurrencyCode)
@@ -2240,6 +2112,11 @@ func appendValidationError(list *[]validationError, sheet string, row int, field
 	*list = append(*list, validationError{Sheet: sheet, Row: row, Field: field, Code: code, Extra: extra})
 }
 
+// message 单条校验错误的提示文本
+func (e validationError) message() string {
+	return e.Field + formatValidationSuffix(e.Code, e.Field, e.Extra)
+}
+
 func formatValidationErrors(errors []validationError) []string {
 	if len(errors) == 0 {
 		return nil
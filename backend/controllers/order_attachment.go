@@ -33,6 +33,9 @@ func ListOrderAttachments(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "订单ID格式错误"})
 		return
 	}
+	if !ensureOrderAccessible(c, orderID) {
+		return
+	}
 
 	var attachments []models.OrderAttachment
 	if err := database.DB.Where("order_id = ?", orderID).Order("created_at DESC").Find(&attachments).Error; err != nil {
@@ -77,6 +80,9 @@ func UploadOrderAttachment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "订单ID格式错误"})
 		return
 	}
+	if !ensureOrderAccessible(c, orderID) {
+		return
+	}
 
 	fileType := strings.TrimSpace(c.PostForm("file_type"))
 	if fileType == "" {
@@ -352,6 +358,9 @@ func AttachOrderMaterial(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "订单ID格式错误"})
 		return
 	}
+	if !ensureOrderAccessible(c, orderID) {
+		return
+	}
 
 	var req struct {
 		MaterialID uint64 `json:"material_id" binding:"required"`
@@ -384,13 +393,10 @@ func AttachOrderMaterial(c *gin.Context) {
 		return
 	}
 
-	// 权限检查：非管理员只能引用自己创建的素材
-	if roleID := c.GetUint("role_id"); roleID != 1 {
-		userID := uint64(c.GetUint("user_id"))
-		if material.CreatedBy != userID {
-			c.JSON(http.StatusForbidden, gin.H{"error": "无权引用该素材"})
-			return
-		}
+	// 权限检查：只能引用数据范围内的素材
+	if !canAccessMaterial(c, material) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权引用该素材"})
+		return
 	}
 
 	baseName := strings.TrimSuffix(material.FileName, filepath.Ext(material.FileName))
@@ -519,6 +525,9 @@ func DownloadOrderAttachment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "附件ID格式错误"})
 		return
 	}
+	if !ensureOrderAccessible(c, orderID) {
+		return
+	}
 
 	var attachment models.OrderAttachment
 	if err := database.DB.Where("order_id = ? AND id = ?", orderID, attachmentID).First(&attachment).Error; err != nil {
@@ -565,6 +574,9 @@ func DeleteOrderAttachment(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "附件ID格式错误"})
 		return
 	}
+	if !ensureOrderAccessible(c, orderID) {
+		return
+	}
 
 	var attachment models.OrderAttachment
 	if err := database.DB.Where("order_id = ? AND id = ?", orderID, attachmentID).First(&attachment).Error; err != nil {
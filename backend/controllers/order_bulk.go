@@ -0,0 +1,321 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
+)
+
+const (
+	bulkOrderChunkSize = 100
+	bulkOrderMaxCount  = 5000
+
+	bulkActionStatus    = "status"
+	bulkActionReassign  = "reassign"
+	bulkActionWarehouse = "warehouse"
+	bulkActionDelete    = "delete"
+)
+
+var bulkOrderActionNames = map[string]string{
+	bulkActionStatus:    "修改状态",
+	bulkActionReassign:  "转交负责人",
+	bulkActionWarehouse: "设置发货仓库",
+	bulkActionDelete:    "删除订单",
+}
+
+// bulkOrderRequest 批量操作请求。ids 为空且 use_filters 为 true 时，
+// 按与订单列表相同的查询参数（tab、time_field、fuzzy_field 等）选取订单
+type bulkOrderRequest struct {
+	Action                string   `json:"action" binding:"required"`
+	IDs                   []uint64 `json:"ids"`
+	UseFilters            bool     `json:"use_filters"`
+	Status                *int8    `json:"status"`
+	OwnerID               uint     `json:"owner_id"`
+	OwnerName             string   `json:"owner_name"`
+	ShippingWarehouseCode string   `json:"shipping_warehouse_code"`
+	Remark                string   `json:"remark"`
+}
+
+type bulkOrderResult struct {
+	ID      uint64 `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// bulkOrderAction 对单个订单执行的批量操作，返回事务提交后需要删除的附件文件
+type bulkOrderAction func(tx *gorm.DB, order *models.OrderInfo) ([]models.OrderAttachment, error)
+
+// bulkOrderError 可直接展示给用户的单个订单处理失败原因
+type bulkOrderError string
+
+func (e bulkOrderError) Error() string {
+	return string(e)
+}
+
+// BulkOrders 批量修改订单状态、转交负责人、设置发货仓库或删除订单，按批次在事务中执行并返回每个订单的处理结果
+func BulkOrders(c *gin.Context) {
+	var req bulkOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	action, err := buildBulkOrderAction(c, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetIDs, results, err := resolveBulkOrderIDs(c, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	for start := 0; start < len(targetIDs); start += bulkOrderChunkSize {
+		end := start + bulkOrderChunkSize
+		if end > len(targetIDs) {
+			end = len(targetIDs)
+		}
+		results = append(results, runBulkOrderChunk(c.Request.Context(), targetIDs[start:end], action)...)
+	}
+
+	successCount := 0
+	for _, result := range results {
+		if result.Success {
+			successCount++
+		}
+	}
+	failureCount := len(results) - successCount
+
+	actionName := bulkOrderActionNames[req.Action]
+	utils.LogAction(currentUserID(c), c.GetString("username"), "批量"+actionName, "订单",
+		fmt.Sprintf("批量%s：成功%d条，失败%d条", actionName, successCount, failureCount),
+		utils.GetClientIP(c.Request), c.Request.UserAgent(), 1)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       fmt.Sprintf("处理完成：成功%d条，失败%d条", successCount, failureCount),
+		"total":         len(results),
+		"success_count": successCount,
+		"failure_count": failureCount,
+		"results":       results,
+	})
+}
+
+// buildBulkOrderAction 校验操作参数并生成对单个订单的处理函数
+func buildBulkOrderAction(c *gin.Context, req *bulkOrderRequest) (bulkOrderAction, error) {
+	operatorID := currentUserID(c)
+	operatorName := c.GetString("username")
+
+	switch req.Action {
+	case bulkActionStatus:
+		if req.Status == nil {
+			return nil, errors.New("请选择目标状态")
+		}
+		if !models.IsValidOrderStatus(*req.Status) {
+			return nil, errors.New("目标状态无效")
+		}
+		to := *req.Status
+		return func(tx *gorm.DB, order *models.OrderInfo) ([]models.OrderAttachment, error) {
+			return nil, transitionOrderStatus(tx, order, to, operatorID, operatorName, req.Remark)
+		}, nil
+
+	case bulkActionReassign:
+		if req.OwnerID == 0 {
+			return nil, errors.New("请选择负责人")
+		}
+		var owner models.User
+		if err := database.DB.First(&owner, req.OwnerID).Error; err != nil {
+			return nil, errors.New("负责人不存在")
+		}
+		updates := map[string]interface{}{
+			"created_by": uint64(owner.ID),
+			"updated_by": uint64(operatorID),
+		}
+		if name := strings.TrimSpace(req.OwnerName); name != "" {
+			updates["owner_name"] = name
+		}
+		return func(tx *gorm.DB, order *models.OrderInfo) ([]models.OrderAttachment, error) {
+			return nil, tx.Model(&models.OrderInfo{}).Where("id = ?", order.ID).Updates(updates).Error
+		}, nil
+
+	case bulkActionWarehouse:
+		code := strings.ToUpper(strings.TrimSpace(req.ShippingWarehouseCode))
+		if code == "" {
+			return nil, errors.New("请选择发货仓库")
+		}
+		if shippingSet := loadShippingWarehouseSet(); shippingSet != nil {
+			if _, ok := shippingSet[code]; !ok {
+				return nil, fmt.Errorf("发货仓库 不在系统字典中[%s]", req.ShippingWarehouseCode)
+			}
+		}
+		return func(tx *gorm.DB, order *models.OrderInfo) ([]models.OrderAttachment, error) {
+			return nil, tx.Model(&models.OrderInfo{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+				"shipping_warehouse_code": code,
+				"updated_by":              uint64(operatorID),
+			}).Error
+		}, nil
+
+	case bulkActionDelete:
+		return func(tx *gorm.DB, order *models.OrderInfo) ([]models.OrderAttachment, error) {
+			attachments, message, err := deleteOrderWithAttachments(tx, order)
+			if err != nil {
+				return nil, bulkOrderError(message)
+			}
+			return attachments, nil
+		}, nil
+
+	default:
+		return nil, errors.New("不支持的批量操作")
+	}
+}
+
+// resolveBulkOrderIDs 解析需要处理的订单ID，仅保留当前用户数据范围内的订单；
+// 指定ID中不存在或无权访问的订单直接记为失败
+func resolveBulkOrderIDs(c *gin.Context, req *bulkOrderRequest) ([]uint64, []bulkOrderResult, error) {
+	var results []bulkOrderResult
+
+	if len(req.IDs) == 0 {
+		if !req.UseFilters {
+			return nil, nil, errors.New("请提供订单ID列表或筛选条件")
+		}
+		query := database.DB.Model(&models.OrderInfo{})
+		if tab := c.Query("tab"); tab == "factory" || tab == "platform" {
+			query = query.Where("order_type = ?", tab)
+		}
+		query = applyOrderFilters(c, query)
+
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			return nil, nil, errors.New("查询订单失败")
+		}
+		if total > bulkOrderMaxCount {
+			return nil, nil, fmt.Errorf("单次最多处理%d条订单，请缩小筛选范围", bulkOrderMaxCount)
+		}
+
+		var ids []uint64
+		if err := query.Order("id").Pluck("id", &ids).Error; err != nil {
+			return nil, nil, errors.New("查询订单失败")
+		}
+		return ids, results, nil
+	}
+
+	requested := make([]uint64, 0, len(req.IDs))
+	seen := make(map[uint64]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		requested = append(requested, id)
+	}
+	if len(requested) > bulkOrderMaxCount {
+		return nil, nil, fmt.Errorf("单次最多处理%d条订单", bulkOrderMaxCount)
+	}
+
+	visible := make(map[uint64]bool, len(requested))
+	for start := 0; start < len(requested); start += bulkOrderChunkSize {
+		end := start + bulkOrderChunkSize
+		if end > len(requested) {
+			end = len(requested)
+		}
+		var ids []uint64
+		query := applyDataScope(c, database.DB.Model(&models.OrderInfo{}).Where("id IN ?", requested[start:end]))
+		if err := query.Pluck("id", &ids).Error; err != nil {
+			return nil, nil, errors.New("查询订单失败")
+		}
+		for _, id := range ids {
+			visible[id] = true
+		}
+	}
+
+	targetIDs := make([]uint64, 0, len(visible))
+	for _, id := range requested {
+		if visible[id] {
+			targetIDs = append(targetIDs, id)
+			continue
+		}
+		results = append(results, bulkOrderResult{ID: id, Error: "订单不存在或无权访问"})
+	}
+	return targetIDs, results, nil
+}
+
+// runBulkOrderChunk 在一个事务中处理一批订单，单个订单失败时回滚到保存点，不影响同批次其他订单；
+// 已删除订单的附件文件在事务提交成功后才删除
+func runBulkOrderChunk(ctx context.Context, ids []uint64, action bulkOrderAction) []bulkOrderResult {
+	results := make([]bulkOrderResult, 0, len(ids))
+	failAll := func(message string) []bulkOrderResult {
+		failed := make([]bulkOrderResult, 0, len(ids))
+		for _, id := range ids {
+			failed = append(failed, bulkOrderResult{ID: id, Error: message})
+		}
+		return failed
+	}
+
+	tx := database.DB.Begin()
+	if tx.Error != nil {
+		return failAll("无法启动数据库事务")
+	}
+
+	var orders []models.OrderInfo
+	if err := tx.Where("id IN ?", ids).Find(&orders).Error; err != nil {
+		tx.Rollback()
+		return failAll("查询订单失败")
+	}
+	orderMap := make(map[uint64]*models.OrderInfo, len(orders))
+	for i := range orders {
+		orderMap[orders[i].ID] = &orders[i]
+	}
+
+	var removed []models.OrderAttachment
+	for _, id := range ids {
+		order, ok := orderMap[id]
+		if !ok {
+			results = append(results, bulkOrderResult{ID: id, Error: "订单不存在"})
+			continue
+		}
+
+		savepoint := fmt.Sprintf("bulk_order_%d", id)
+		if err := tx.SavePoint(savepoint).Error; err != nil {
+			tx.Rollback()
+			return failAll("无法创建事务保存点")
+		}
+		files, err := action(tx, order)
+		if err != nil {
+			tx.RollbackTo(savepoint)
+			results = append(results, bulkOrderResult{ID: id, Error: bulkOrderErrorMessage(err)})
+			continue
+		}
+		removed = append(removed, files...)
+		results = append(results, bulkOrderResult{ID: id, Success: true})
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return failAll("提交事务失败")
+	}
+	deleteOrderAttachmentFiles(ctx, removed)
+	return results
+}
+
+// bulkOrderErrorMessage 将处理错误转换为可展示的提示，数据库错误不直接暴露
+func bulkOrderErrorMessage(err error) string {
+	var transitionErr *orderTransitionError
+	var messageErr bulkOrderError
+	switch {
+	case errors.As(err, &transitionErr), errors.As(err, &messageErr), errors.Is(err, errOrderStatusChanged):
+		return err.Error()
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "订单不存在"
+	default:
+		return "处理失败"
+	}
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
+)
+
+// orderBundleFolders 压缩包中各类附件所在的目录
+var orderBundleFolders = map[string]string{
+	"material_image": "素材图",
+	"shipping_label": "面单",
+}
+
+// orderBundleNameReplacer 替换文件名中不能出现在压缩包路径里的字符
+var orderBundleNameReplacer = strings.NewReplacer(
+	"/", "_", "\\", "_", ":", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_", "|", "_",
+)
+
+// orderBundleMissing 未能写入压缩包的附件
+type orderBundleMissing struct {
+	GSPOrderNo string
+	FileName   string
+	Reason     string
+}
+
+// ExportOrderBundle 按订单列表的筛选条件导出 ZIP：包含订单表格及各订单的素材图、面单文件，
+// 附件按"GSP订单号_货号"命名；读取失败的附件记录在"缺失文件.txt"中
+func ExportOrderBundle(c *gin.Context) {
+	columns, err := parseOrderExportColumns(c.Query("columns"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	f, message, err := buildOrderExportWorkbook(c, columns)
+	if err != nil {
+		log.Printf("failed to build order workbook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", orderExportFileName(c, "zip")))
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	if err := writeOrderBundle(c, archive, f); err != nil {
+		// 响应已开始发送，不再关闭压缩包，客户端会收到不完整的文件
+		log.Printf("failed to export order bundle: %v", err)
+		c.Abort()
+		return
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("failed to finish order bundle: %v", err)
+	}
+}
+
+func writeOrderBundle(c *gin.Context, archive *zip.Writer, f *excelize.File) error {
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: "订单.xlsx", Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := f.Write(entry); err != nil {
+		return err
+	}
+
+	fileTypes := make([]string, 0, len(orderBundleFolders))
+	for fileType := range orderBundleFolders {
+		fileTypes = append(fileTypes, fileType)
+	}
+	usedNames := make(map[string]int)
+	var missing []orderBundleMissing
+
+	err = eachOrderBatch(orderExportQuery(c), func(orders []models.OrderInfo) error {
+		ids := make([]uint64, 0, len(orders))
+		orderMap := make(map[uint64]*models.OrderInfo, len(orders))
+		for i := range orders {
+			ids = append(ids, orders[i].ID)
+			orderMap[orders[i].ID] = &orders[i]
+		}
+
+		var attachments []models.OrderAttachment
+		if err := database.DB.Where("order_id IN ? AND file_type IN ?", ids, fileTypes).
+			Order("order_id DESC, id ASC").Find(&attachments).Error; err != nil {
+			return err
+		}
+
+		for _, attachment := range attachments {
+			order := orderMap[attachment.OrderID]
+			name := orderBundleEntryName(order, &attachment, usedNames)
+			reader, err := utils.OpenAttachment(c.Request.Context(), attachment.Storage, attachment.FilePath)
+			if err != nil {
+				log.Printf("order bundle: open attachment %d failed: %v", attachment.ID, err)
+				missing = append(missing, orderBundleMissing{GSPOrderNo: order.GSPOrderNo, FileName: attachment.FileName, Reason: "文件读取失败"})
+				continue
+			}
+			err = copyOrderBundleEntry(archive, name, attachment.UpdatedAt, reader)
+			_ = reader.Close()
+			if err != nil {
+				return fmt.Errorf("write %s: %w", name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+	entry, err = archive.CreateHeader(&zip.FileHeader{Name: "缺失文件.txt", Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	var builder strings.Builder
+	builder.WriteString("GSP订单号\t文件名\t原因\r\n")
+	for _, item := range missing {
+		fmt.Fprintf(&builder, "%s\t%s\t%s\r\n", item.GSPOrderNo, item.FileName, item.Reason)
+	}
+	_, err = io.WriteString(entry, builder.String())
+	return err
+}
+
+// copyOrderBundleEntry 以不压缩方式写入附件，图片与 PDF 本身已压缩
+func copyOrderBundleEntry(archive *zip.Writer, name string, modified time.Time, reader io.Reader) error {
+	entry, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, reader)
+	return err
+}
+
+// orderBundleEntryName 生成附件在压缩包中的路径，如 素材图/GSP123_A001.png，重名时追加序号
+func orderBundleEntryName(order *models.OrderInfo, attachment *models.OrderAttachment, usedNames map[string]int) string {
+	base := strings.TrimSpace(order.GSPOrderNo)
+	if base == "" {
+		base = fmt.Sprintf("订单%d", order.ID)
+	}
+	if itemNo := strings.TrimSpace(order.ItemNo); itemNo != "" {
+		base += "_" + itemNo
+	}
+	base = orderBundleNameReplacer.Replace(base)
+
+	ext := strings.ToLower(attachment.FileExt)
+	if ext == "" {
+		ext = strings.ToLower(filepath.Ext(attachment.FileName))
+	}
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	name := path.Join(orderBundleFolders[attachment.FileType], base+ext)
+	usedNames[name]++
+	if count := usedNames[name]; count > 1 {
+		name = path.Join(orderBundleFolders[attachment.FileType], fmt.Sprintf("%s_%d%s", base, count, ext))
+	}
+	return name
+}
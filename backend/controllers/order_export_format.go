@@ -0,0 +1,500 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/config"
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+// orderExportBatchSize 导出时每批读取的订单数
+const orderExportBatchSize = 500
+
+// orderFieldIndexes 订单 JSON 字段名到结构体字段下标的映射
+var orderFieldIndexes = func() map[string]int {
+	indexes := make(map[string]int)
+	orderType := reflect.TypeOf(models.OrderInfo{})
+	for i := 0; i < orderType.NumField(); i++ {
+		name := strings.Split(orderType.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			indexes[name] = i
+		}
+	}
+	return indexes
+}()
+
+// orderExportColumn 可导出的订单列
+type orderExportColumn struct {
+	Key   string `json:"key"`   // OrderInfo 的 JSON 名
+	Label string `json:"label"` // 导出表头
+}
+
+// orderExportExtraColumns 导入字段之外可导出的列
+var orderExportExtraColumns = []orderExportColumn{
+	{"id", "订单ID"},
+	{"order_type", "订单类型"},
+	{"status", "订单状态"},
+	{"item_count", "商品数量"},
+	{"created_at", "录入时间"},
+	{"updated_at", "更新时间"},
+}
+
+// 导出 Excel 时各工作表的默认列，与运营提交表格模板一致
+var (
+	platformExportColumns = []string{
+		"gsp_order_no", "shipping_warehouse_code", "shop_code", "owner_name", "product_name", "spec", "item_no",
+		"seller_sku", "platform_sku", "platform_skc", "platform_spu", "product_price", "special_product_note",
+		"expected_fulfillment_qty", "postal_code", "country", "province", "city", "district", "address_line1",
+		"address_line2", "customer_full_name", "customer_last_name", "customer_first_name", "phone_number", "email", "tax_number",
+	}
+	factoryExportColumns = []string{
+		"gsp_order_no", "order_created_at", "shop_code", "owner_name", "product_name", "spec", "item_no",
+		"seller_sku", "platform_sku", "platform_skc", "platform_spu", "product_price", "special_product_note",
+		"expected_fulfillment_qty", "postal_code", "country", "province", "city", "district", "address_line1",
+		"address_line2", "customer_full_name", "customer_last_name", "customer_first_name", "phone_number", "email", "tax_number",
+	}
+)
+
+// orderExportColumnOptions 全部可导出的列，导入字段在前
+func orderExportColumnOptions() []orderExportColumn {
+	options := make([]orderExportColumn, 0, len(orderImportFields)+len(orderExportExtraColumns))
+	for _, field := range orderImportFields {
+		options = append(options, orderExportColumn{Key: field.Key, Label: field.Label})
+	}
+	return append(options, orderExportExtraColumns...)
+}
+
+func findOrderExportColumn(key string) (orderExportColumn, bool) {
+	for _, column := range orderExportColumnOptions() {
+		if column.Key == key {
+			return column, true
+		}
+	}
+	return orderExportColumn{}, false
+}
+
+// parseOrderExportColumns 解析逗号分隔的导出列，为空时返回 nil 表示使用默认列
+func parseOrderExportColumns(value string) ([]orderExportColumn, error) {
+	var columns []orderExportColumn
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		column, ok := findOrderExportColumn(key)
+		if !ok {
+			return nil, fmt.Errorf("不支持的导出列: %s", key)
+		}
+		seen[key] = true
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// orderExportColumnsByKeys 按字段名取导出列，用于默认列
+func orderExportColumnsByKeys(keys []string) []orderExportColumn {
+	columns := make([]orderExportColumn, 0, len(keys))
+	for _, key := range keys {
+		if column, ok := findOrderExportColumn(key); ok {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// orderFieldValue 按 JSON 字段名读取订单字段：时间格式化为 2006-01-02 15:04:05，
+// 订单状态转换为名称，数值保持原类型以便 Excel 按数字处理
+func orderFieldValue(order *models.OrderInfo, key string) interface{} {
+	if key == "status" {
+		return models.OrderStatusName(order.Status)
+	}
+	index, ok := orderFieldIndexes[key]
+	if !ok {
+		return ""
+	}
+	switch value := reflect.ValueOf(order).Elem().Field(index).Interface().(type) {
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.Format("2006-01-02 15:04:05")
+	case *time.Time:
+		if value == nil || value.IsZero() {
+			return ""
+		}
+		return value.Format("2006-01-02 15:04:05")
+	default:
+		return value
+	}
+}
+
+// orderFieldText 按 JSON 字段名读取订单字段并格式化为文本
+func orderFieldText(order *models.OrderInfo, key string) string {
+	switch value := orderFieldValue(order, key).(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// orderExportQuery 按订单列表的筛选条件构造导出查询，tab 为 platform/factory 时仅导出该类型
+func orderExportQuery(c *gin.Context) *gorm.DB {
+	query := database.DB.Model(&models.OrderInfo{})
+	if tab := c.Query("tab"); tab == "platform" || tab == "factory" {
+		query = query.Where("order_type = ?", tab)
+	}
+	return applyOrderFilters(c, query)
+}
+
+// eachOrderBatch 按ID倒序分批读取订单，避免一次性加载全部数据
+func eachOrderBatch(query *gorm.DB, fn func([]models.OrderInfo) error) error {
+	base := query.Session(&gorm.Session{})
+	var lastID uint64
+	for {
+		batchQuery := base
+		if lastID > 0 {
+			batchQuery = batchQuery.Where("id < ?", lastID)
+		}
+		var batch []models.OrderInfo
+		if err := batchQuery.Order("id DESC").Limit(orderExportBatchSize).Find(&batch).Error; err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < orderExportBatchSize {
+			return nil
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+func orderExportFileName(c *gin.Context, ext string) string {
+	scope := "all"
+	if tab := c.Query("tab"); tab == "platform" || tab == "factory" {
+		scope = tab
+	}
+	return fmt.Sprintf("orders_%s_%d.%s", scope, time.Now().Unix(), ext)
+}
+
+// exportOrdersCSV 以 UTF-8（带 BOM）CSV 流式导出订单；未指定列时导出订单类型及全部导入字段，
+// 表头与导入时的内置表头一致
+func exportOrdersCSV(c *gin.Context, columns []orderExportColumn) {
+	if columns == nil {
+		columns = []orderExportColumn{{Key: "order_type", Label: "订单类型"}}
+		for _, field := range orderImportFields {
+			columns = append(columns, orderExportColumn{Key: field.Key, Label: field.Label})
+		}
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", orderExportFileName(c, "csv")))
+	c.Status(http.StatusOK)
+
+	if _, err := c.Writer.Write(utf8BOM); err != nil {
+		log.Printf("failed to write csv export: %v", err)
+		return
+	}
+	writer := csv.NewWriter(c.Writer)
+	headers := make([]string, 0, len(columns))
+	for _, column := range columns {
+		headers = append(headers, column.Label)
+	}
+	if err := writer.Write(headers); err != nil {
+		log.Printf("failed to write csv export: %v", err)
+		return
+	}
+
+	err := eachOrderBatch(orderExportQuery(c), func(orders []models.OrderInfo) error {
+		for i := range orders {
+			record := make([]string, 0, len(columns))
+			for _, column := range columns {
+				record = append(record, orderFieldText(&orders[i], column.Key))
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		log.Printf("failed to export orders as csv: %v", err)
+		c.Abort()
+		return
+	}
+	writer.Flush()
+}
+
+// exportOrdersJSONLines 以 JSON Lines 流式导出订单，每行一个订单对象，字段与订单接口一致；
+// 指定 columns 时只输出所选字段，并按给定顺序排列
+func exportOrdersJSONLines(c *gin.Context, columns []orderExportColumn) {
+	c.Header("Content-Type", "application/x-ndjson; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", orderExportFileName(c, "jsonl")))
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	encoder.SetEscapeHTML(false)
+	err := eachOrderBatch(orderExportQuery(c), func(orders []models.OrderInfo) error {
+		for i := range orders {
+			var line interface{} = &orders[i]
+			if columns != nil {
+				line = orderJSONColumns(&orders[i], columns)
+			}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		log.Printf("failed to export orders as jsonl: %v", err)
+		c.Abort()
+	}
+}
+
+// orderJSONObject 按给定顺序序列化字段的 JSON 对象
+type orderJSONObject struct {
+	keys   []string
+	values []interface{}
+}
+
+func (o orderJSONObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// orderJSONColumns 取订单的所选字段，字段值与订单接口返回的类型一致
+func orderJSONColumns(order *models.OrderInfo, columns []orderExportColumn) orderJSONObject {
+	object := orderJSONObject{
+		keys:   make([]string, 0, len(columns)),
+		values: make([]interface{}, 0, len(columns)),
+	}
+	value := reflect.ValueOf(order).Elem()
+	for _, column := range columns {
+		index, ok := orderFieldIndexes[column.Key]
+		if !ok {
+			continue
+		}
+		object.keys = append(object.keys, column.Key)
+		object.values = append(object.values, value.Field(index).Interface())
+	}
+	return object
+}
+
+// orderExportSheet 导出 Excel 时的工作表
+type orderExportSheet struct {
+	Name      string
+	OrderType string
+	Columns   []string // 未指定导出列时使用的默认列
+}
+
+var orderExportSheets = []orderExportSheet{
+	{Name: "平台面单", OrderType: "platform", Columns: platformExportColumns},
+	{Name: "工厂物流", OrderType: "factory", Columns: factoryExportColumns},
+}
+
+// exportOrdersXLSX 以 StreamWriter 分批写入订单，行数据先写入临时文件，内存占用不随订单数增长；
+// template 为真时基于导出模板生成，保留模板的表头样式、列宽及其他工作表
+func exportOrdersXLSX(c *gin.Context, columns []orderExportColumn) {
+	f, message, err := buildOrderExportWorkbook(c, columns)
+	if err != nil {
+		log.Printf("failed to build order workbook: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", orderExportFileName(c, "xlsx")))
+	c.Status(http.StatusOK)
+	if err := f.Write(c.Writer); err != nil {
+		log.Printf("failed to send workbook: %v", err)
+		c.Abort()
+	}
+}
+
+// buildOrderExportWorkbook 按请求的筛选条件生成订单工作簿，失败时返回提示信息
+func buildOrderExportWorkbook(c *gin.Context, columns []orderExportColumn) (*excelize.File, string, error) {
+	sheets := orderExportSheets
+	if tab := c.Query("tab"); tab == "platform" || tab == "factory" {
+		for _, sheet := range orderExportSheets {
+			if sheet.OrderType == tab {
+				sheets = []orderExportSheet{sheet}
+			}
+		}
+	}
+
+	useTemplate := requestBool(c, "template")
+	f, err := openOrderExportWorkbook(useTemplate, sheets)
+	if err != nil {
+		return nil, "导出失败，模板不可用", err
+	}
+
+	headerStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"D9E1F2"}},
+	})
+	if err != nil {
+		_ = f.Close()
+		return nil, "导出失败", err
+	}
+
+	for _, sheet := range sheets {
+		sheetColumns := columns
+		if sheetColumns == nil {
+			sheetColumns = orderExportColumnsByKeys(sheet.Columns)
+		}
+		style := headerStyle
+		if useTemplate {
+			// 沿用模板表头的样式
+			if templateStyle, err := f.GetCellStyle(sheet.Name, "A1"); err == nil && templateStyle != 0 {
+				style = templateStyle
+			}
+		}
+		query := orderExportQuery(c).Where("order_type = ?", sheet.OrderType)
+		if err := writeOrderExportSheet(f, sheet.Name, sheetColumns, style, !useTemplate, query); err != nil {
+			_ = f.Close()
+			return nil, "导出失败", fmt.Errorf("export %s orders: %w", sheet.OrderType, err)
+		}
+	}
+	return f, "", nil
+}
+
+// openOrderExportWorkbook 打开导出模板或新建工作簿，并确保只包含待导出的订单工作表
+func openOrderExportWorkbook(useTemplate bool, sheets []orderExportSheet) (*excelize.File, error) {
+	if !useTemplate {
+		f := excelize.NewFile()
+		if err := f.SetSheetName(f.GetSheetName(0), sheets[0].Name); err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		for _, sheet := range sheets[1:] {
+			if _, err := f.NewSheet(sheet.Name); err != nil {
+				_ = f.Close()
+				return nil, err
+			}
+		}
+		return f, nil
+	}
+
+	f, err := excelize.OpenFile(config.AppConfig.ExportTemplate)
+	if err != nil {
+		return nil, err
+	}
+	selected := make(map[string]bool, len(sheets))
+	for _, sheet := range sheets {
+		selected[sheet.Name] = true
+		if index, _ := f.GetSheetIndex(sheet.Name); index < 0 {
+			if _, err := f.NewSheet(sheet.Name); err != nil {
+				_ = f.Close()
+				return nil, err
+			}
+		}
+	}
+	// 仅导出单个类型时移除模板中另一类型的工作表
+	for _, sheet := range orderExportSheets {
+		if !selected[sheet.Name] {
+			if err := f.DeleteSheet(sheet.Name); err != nil {
+				_ = f.Close()
+				return nil, err
+			}
+		}
+	}
+	if index, err := f.GetSheetIndex(sheets[0].Name); err == nil && index >= 0 {
+		f.SetActiveSheet(index)
+	}
+	return f, nil
+}
+
+// writeOrderExportSheet 覆盖写入工作表：首行为表头，其后逐批写入订单
+func writeOrderExportSheet(f *excelize.File, sheet string, columns []orderExportColumn, headerStyle int, setWidth bool, query *gorm.DB) error {
+	writer, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	if setWidth {
+		if err := writer.SetColWidth(1, len(columns), 18); err != nil {
+			return err
+		}
+	}
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = excelize.Cell{StyleID: headerStyle, Value: column.Label}
+	}
+	if err := writer.SetRow("A1", header); err != nil {
+		return err
+	}
+
+	rowNum := 2 // 数据从第二行开始
+	err = eachOrderBatch(query, func(orders []models.OrderInfo) error {
+		for i := range orders {
+			values := make([]interface{}, len(columns))
+			for j, column := range columns {
+				values[j] = orderFieldValue(&orders[i], column.Key)
+			}
+			cell, _ := excelize.CoordinatesToCellName(1, rowNum)
+			if err := writer.SetRow(cell, values); err != nil {
+				return err
+			}
+			rowNum++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// GetOrderExportColumns 获取可导出的列及各工作表的默认列
+func GetOrderExportColumns(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"columns": orderExportColumnOptions(),
+		"defaults": gin.H{
+			"platform": platformExportColumns,
+			"factory":  factoryExportColumns,
+		},
+	})
+}
@@ -0,0 +1,424 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/config"
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+const (
+	importJobTypeOrder = "order"
+
+	// orderImportChunkSize 每个事务写入的订单行数
+	orderImportChunkSize = 200
+
+	importErrorColumnTitle = "错误信息"
+	importErrorSheetName   = "错误汇总"
+)
+
+// orderImportOptions 订单导入选项
+type orderImportOptions struct {
+	OperatorID uint
+	Format     string                // 文件格式：xlsx / csv / jsonl
+	OrderType  string                // 文本格式未标明订单类型时的默认类型
+	DryRun     bool                  // 仅预检：解析、校验并比对现有数据，不写入订单
+	OnError    string                // 校验错误处理方式，见 models.ImportOnErrorAbort / models.ImportOnErrorSkip
+	Profile    *models.ImportProfile // 导入模板，nil 表示内置规则
+}
+
+// skipInvalid 是否跳过出错的行
+func (o orderImportOptions) skipInvalid() bool {
+	return o.OnError == models.ImportOnErrorSkip
+}
+
+// startOrderImportJob 创建订单导入任务并在后台执行
+func startOrderImportJob(fileName string, data []byte, opts orderImportOptions) (*models.ImportJob, error) {
+	job := models.ImportJob{
+		Type:      importJobTypeOrder,
+		FileName:  fileName,
+		Format:    opts.Format,
+		DryRun:    opts.DryRun,
+		OnError:   opts.OnError,
+		CreatedBy: opts.OperatorID,
+	}
+	if opts.Profile != nil {
+		job.ProfileID = &opts.Profile.ID
+	}
+	created, err := createImportJob(job)
+	if err != nil {
+		return nil, err
+	}
+	go runOrderImportJob(created.ID, data, opts)
+	return created, nil
+}
+
+// parseOrderWorkbook 按导入模板解析平台面单与工厂物流两个工作表，模板未配置的工作表使用内置规则
+func parseOrderWorkbook(sheets workbookSheets, profile *models.ImportProfile) []sheetParseResult {
+	defaultAddress := getConfigValue("default_address")
+	if defaultAddress == "" {
+		defaultAddress = config.AppConfig.LocalBaseURL
+	}
+	shippingSet := loadShippingWarehouseSet()
+
+	return []sheetParseResult{
+		parsePlatformSheet(sheets, defaultAddress, shippingSet, profile.SheetMapping("platform")),
+		parseFactorySheet(sheets, defaultAddress, shippingSet, profile.SheetMapping("factory")),
+	}
+}
+
+// summarizeImportResults 汇总各工作表的有效行、校验错误以及涉及的数据行数
+func summarizeImportResults(results []sheetParseResult) (rows []importRow, errs []validationError, totalRows, failedRows int) {
+	all := make(map[string]bool)
+	failed := make(map[string]bool)
+	for _, result := range results {
+		for _, row := range result.Rows {
+			rows = append(rows, row)
+			all[fmt.Sprintf("%s#%d", row.Sheet, row.Row)] = true
+		}
+		for _, e := range result.Errors {
+			errs = append(errs, e)
+			if e.Row <= result.HeaderRow {
+				continue
+			}
+			key := fmt.Sprintf("%s#%d", e.Sheet, e.Row)
+			all[key] = true
+			failed[key] = true
+		}
+	}
+	return rows, errs, len(all), len(failed)
+}
+
+// runOrderImportJob 执行订单导入：解析、校验，全部通过后分批写入；存在错误时生成错误报告。
+// 跳过模式下仅写入没有错误的行；预检模式下不写入订单，仅生成预检结果
+func runOrderImportJob(jobID uint64, data []byte, opts orderImportOptions) {
+	defer keepImportJobAlive(jobID)()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("order import job %d panic: %v", jobID, r)
+			finishImportJob(jobID, models.ImportJobFailed, fmt.Sprintf("导入异常: %v", r), nil, nil)
+		}
+	}()
+
+	updateImportJob(jobID, map[string]interface{}{
+		"status":     models.ImportJobRunning,
+		"started_at": time.Now(),
+	})
+
+	file, err := parseOrderImportFile(opts.Format, data, opts.OrderType, opts.Profile)
+	if err != nil {
+		finishImportJob(jobID, models.ImportJobFailed, fmt.Sprintf("无法解析文件: %v", err), nil, nil)
+		return
+	}
+
+	results := parseOrderWorkbook(file.Sheets, opts.Profile)
+	rows, validationErrors, totalRows, failedRows := summarizeImportResults(results)
+	if len(rows) == 0 && len(validationErrors) == 0 {
+		finishImportJob(jobID, models.ImportJobFailed, "文件中未找到可导入的数据", nil, nil)
+		return
+	}
+	updateImportJob(jobID, map[string]interface{}{"total_rows": totalRows})
+
+	var errorUpdates map[string]interface{}
+	if len(validationErrors) > 0 {
+		errorUpdates = map[string]interface{}{"failed_rows": failedRows}
+		if report, err := buildImportErrorReport(file, results); err != nil {
+			log.Printf("build import error report for job %d failed: %v", jobID, err)
+		} else if reportPath, err := saveImportErrorReport(jobID, report); err != nil {
+			log.Printf("save import error report for job %d failed: %v", jobID, err)
+		} else {
+			errorUpdates["error_report_path"] = reportPath
+		}
+	}
+
+	if opts.skipInvalid() {
+		rows = filterValidImportRows(rows, validationErrors)
+	}
+
+	if opts.DryRun {
+		runOrderImportPreview(jobID, rows, validationErrors, totalRows, failedRows, errorUpdates)
+		return
+	}
+
+	if len(validationErrors) > 0 && !opts.skipInvalid() {
+		finishImportJob(jobID, models.ImportJobFailed,
+			fmt.Sprintf("校验未通过，共%d处错误，请下载错误报告修正后重新导入", len(validationErrors)),
+			validationErrors, errorUpdates)
+		return
+	}
+
+	if len(rows) == 0 {
+		finishImportJob(jobID, models.ImportJobFailed,
+			fmt.Sprintf("没有可导入的有效数据，共%d处错误，请下载错误报告修正后重新导入", len(validationErrors)),
+			validationErrors, errorUpdates)
+		return
+	}
+
+	imported, err := upsertImportRows(jobID, rows, opts.OperatorID)
+	if err != nil {
+		updates := mergeImportUpdates(errorUpdates, map[string]interface{}{"success_rows": imported})
+		finishImportJob(jobID, models.ImportJobFailed, fmt.Sprintf("导入失败: %v（已导入%d条）", err, imported),
+			validationErrors, updates)
+		return
+	}
+
+	updates := mergeImportUpdates(errorUpdates, map[string]interface{}{
+		"processed_rows": imported + failedRows,
+		"success_rows":   imported,
+	})
+	if len(validationErrors) > 0 {
+		finishImportJob(jobID, models.ImportJobPartial,
+			fmt.Sprintf("成功导入%d条订单，跳过%d行（共%d处错误），请下载错误报告查看", imported, failedRows, len(validationErrors)),
+			validationErrors, updates)
+		return
+	}
+	finishImportJob(jobID, models.ImportJobSucceeded, fmt.Sprintf("成功导入%d条订单", imported), nil, updates)
+}
+
+// filterValidImportRows 排除存在校验错误（含已有类似记录提示）的行
+func filterValidImportRows(rows []importRow, errs []validationError) []importRow {
+	invalid := make(map[string]bool, len(errs))
+	for _, e := range errs {
+		invalid[fmt.Sprintf("%s#%d", e.Sheet, e.Row)] = true
+	}
+	valid := make([]importRow, 0, len(rows))
+	for _, row := range rows {
+		if !invalid[fmt.Sprintf("%s#%d", row.Sheet, row.Row)] {
+			valid = append(valid, row)
+		}
+	}
+	return valid
+}
+
+func mergeImportUpdates(base, extra map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(extra))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range extra {
+		merged[key] = value
+	}
+	return merged
+}
+
+// upsertImportRows 分批在事务中写入订单并关联素材，每批提交后更新任务进度
+func upsertImportRows(jobID uint64, rows []importRow, operatorID uint) (int, error) {
+	userID := uint64(operatorID)
+	imported := 0
+	for start := 0; start < len(rows); start += orderImportChunkSize {
+		end := start + orderImportChunkSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		chunk := rows[start:end]
+
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			for i := range chunk {
+				order := &chunk[i].Order
+				order.UpdatedBy = userID
+				if order.CreatedBy == 0 {
+					order.CreatedBy = userID
+				}
+				if err := upsertOrder(tx, order); err != nil {
+					return fmt.Errorf("%s第%d行: %w", chunk[i].Sheet, chunk[i].Row, err)
+				}
+				if err := autoLinkMaterialForOrder(context.Background(), tx, order, operatorID); err != nil {
+					log.Printf("auto link material failed for order %s: %v", order.GSPOrderNo, err)
+				} else {
+					log.Printf("auto link material success for order %s", order.GSPOrderNo)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return imported, err
+		}
+
+		imported += len(chunk)
+		updateImportJob(jobID, map[string]interface{}{
+			"processed_rows": imported,
+			"success_rows":   imported,
+		})
+	}
+	return imported, nil
+}
+
+// buildImportErrorReport 在上传的工作簿中为出错的行追加错误信息列，并附加一张错误汇总表；
+// CSV 与 JSON Lines 先转换为工作簿
+func buildImportErrorReport(file *orderImportFile, results []sheetParseResult) ([]byte, error) {
+	if file.Format != orderFileFormatXLSX {
+		return buildTextImportErrorReport(file.Table, results)
+	}
+
+	f, err := excelize.OpenReader(bytes.NewReader(file.Data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	errorStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Color: "FF0000"}})
+	if err != nil {
+		return nil, err
+	}
+
+	rawSheetNames := make(map[string]string)
+	for _, name := range f.GetSheetList() {
+		rawSheetNames[strings.TrimSpace(name)] = name
+	}
+
+	var allErrors []validationError
+	for _, result := range results {
+		allErrors = append(allErrors, result.Errors...)
+		rawName, ok := rawSheetNames[result.Sheet]
+		if !ok || len(result.Errors) == 0 {
+			continue
+		}
+		if err := annotateSheetErrors(f, rawName, result, errorStyle); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeImportErrorSummary(f, allErrors); err != nil {
+		return nil, err
+	}
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// buildTextImportErrorReport 将文本格式的原始表格写入工作簿，各订单类型的错误统一标注在同一工作表
+func buildTextImportErrorReport(table [][]string, results []sheetParseResult) ([]byte, error) {
+	f := excelize.NewFile()
+	defer func() { _ = f.Close() }()
+
+	if err := f.SetSheetName(f.GetSheetName(0), orderTextSheetName); err != nil {
+		return nil, err
+	}
+	for i, row := range table {
+		if len(row) == 0 {
+			continue
+		}
+		values := make([]interface{}, len(row))
+		for j, value := range row {
+			values[j] = value
+		}
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(orderTextSheetName, cell, &values); err != nil {
+			return nil, err
+		}
+	}
+
+	errorStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Color: "FF0000"}})
+	if err != nil {
+		return nil, err
+	}
+	merged := sheetParseResult{Sheet: orderTextSheetName, HeaderRow: 1}
+	for _, result := range results {
+		merged.Errors = append(merged.Errors, result.Errors...)
+	}
+	if err := annotateSheetErrors(f, orderTextSheetName, merged, errorStyle); err != nil {
+		return nil, err
+	}
+	if err := writeImportErrorSummary(f, merged.Errors); err != nil {
+		return nil, err
+	}
+
+	buffer, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func annotateSheetErrors(f *excelize.File, sheet string, result sheetParseResult, style int) error {
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return err
+	}
+	maxCols := 0
+	for _, row := range rows {
+		if len(row) > maxCols {
+			maxCols = len(row)
+		}
+	}
+	column := maxCols + 1
+
+	messages := make(map[int][]string)
+	for _, e := range result.Errors {
+		row := e.Row
+		if row < result.HeaderRow {
+			row = result.HeaderRow
+		}
+		messages[row] = append(messages[row], e.message())
+	}
+	if _, ok := messages[result.HeaderRow]; !ok {
+		messages[result.HeaderRow] = nil
+	}
+
+	for row, list := range messages {
+		value := strings.Join(list, "；")
+		if row == result.HeaderRow {
+			value = importErrorColumnTitle
+			if len(list) > 0 {
+				value += "：" + strings.Join(list, "；")
+			}
+		}
+		cell, err := excelize.CoordinatesToCellName(column, row)
+		if err != nil {
+			return err
+		}
+		if err := f.SetCellValue(sheet, cell, value); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sheet, cell, cell, style); err != nil {
+			return err
+		}
+	}
+
+	colName, err := excelize.ColumnNumberToName(column)
+	if err != nil {
+		return err
+	}
+	return f.SetColWidth(sheet, colName, colName, 60)
+}
+
+func writeImportErrorSummary(f *excelize.File, errs []validationError) error {
+	if _, err := f.NewSheet(importErrorSheetName); err != nil {
+		return err
+	}
+
+	sorted := make([]validationError, len(errs))
+	copy(sorted, errs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Sheet != sorted[j].Sheet {
+			return sorted[i].Sheet < sorted[j].Sheet
+		}
+		return sorted[i].Row < sorted[j].Row
+	})
+
+	headers := []interface{}{"工作表", "行号", "字段", "错误信息"}
+	if err := f.SetSheetRow(importErrorSheetName, "A1", &headers); err != nil {
+		return err
+	}
+	for i, e := range sorted {
+		cell, _ := excelize.CoordinatesToCellName(1, i+2)
+		values := []interface{}{e.Sheet, e.Row, e.Field, e.message()}
+		if err := f.SetSheetRow(importErrorSheetName, cell, &values); err != nil {
+			return err
+		}
+	}
+	return f.SetColWidth(importErrorSheetName, "D", "D", 60)
+}
@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"haodun_manage/backend/models"
+)
+
+// 导入导出支持的文件格式
+const (
+	orderFileFormatXLSX  = "xlsx"
+	orderFileFormatCSV   = "csv"
+	orderFileFormatJSONL = "jsonl"
+
+	// orderTextSheetName 文本格式生成错误报告时使用的工作表名称
+	orderTextSheetName = "订单"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// orderTypeHeaders 文本格式中标识订单类型的列名
+var orderTypeHeaders = map[string]bool{"ordertype": true, "订单类型": true}
+
+// orderImportFile 解析后的导入文件
+type orderImportFile struct {
+	Format string
+	Data   []byte         // 原始内容，xlsx 生成错误报告时使用
+	Sheets workbookSheets // 按工作表划分的行，文本格式按订单类型拆分
+	Table  [][]string     // 文本格式的原始表格（含表头），生成错误报告时使用
+}
+
+// detectOrderFileFormat 根据显式指定的格式或文件扩展名确定文件格式
+func detectOrderFileFormat(format, fileName string) (string, error) {
+	value := strings.ToLower(strings.TrimSpace(format))
+	if value == "" {
+		value = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+	}
+	switch value {
+	case "", "xlsx", "xlsm":
+		return orderFileFormatXLSX, nil
+	case "csv":
+		return orderFileFormatCSV, nil
+	case "jsonl", "ndjson", "json":
+		return orderFileFormatJSONL, nil
+	default:
+		return "", fmt.Errorf("不支持的文件格式: %s", value)
+	}
+}
+
+// parseOrderImportFile 将上传内容解析为工作表；文本格式默认按 defaultType 归类，
+// 含订单类型列（order_type / 订单类型）时按该列拆分
+func parseOrderImportFile(format string, data []byte, defaultType string, profile *models.ImportProfile) (*orderImportFile, error) {
+	file := &orderImportFile{Format: format, Data: data}
+	if format == orderFileFormatXLSX {
+		sheets, err := parseWorkbook(data)
+		if err != nil {
+			return nil, err
+		}
+		file.Sheets = sheets
+		return file, nil
+	}
+
+	var (
+		table [][]string
+		err   error
+	)
+	if format == orderFileFormatCSV {
+		table, err = parseCSVTable(data)
+	} else {
+		table, err = parseJSONLinesTable(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	file.Table = table
+	file.Sheets = splitTableByOrderType(table, defaultType, profile)
+	return file, nil
+}
+
+// decodeImportText 去除 UTF-8 BOM；内容不是有效的 UTF-8 时按 GBK（GB18030）解码
+func decodeImportText(data []byte) []byte {
+	data = bytes.TrimPrefix(data, utf8BOM)
+	if utf8.Valid(data) {
+		return data
+	}
+	if decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(data); err == nil {
+		return decoded
+	}
+	return data
+}
+
+// parseCSVTable 读取 CSV，行下标与文件行号对应，空行保留为空行
+func parseCSVTable(data []byte) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(decodeImportText(data)))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	var table [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("CSV解析失败: %v", err)
+		}
+		line, _ := reader.FieldPos(0)
+		for len(table) < line-1 {
+			table = append(table, nil)
+		}
+		row := make([]string, len(record))
+		for i, value := range record {
+			row[i] = strings.TrimSpace(value)
+		}
+		table = append(table, row)
+	}
+	return table, nil
+}
+
+// parseJSONLinesTable 将每行一个 JSON 对象转换为表格：表头为所有字段名，
+// 第 N 条记录位于第 N+1 行，与 CSV 保持一致
+func parseJSONLinesTable(data []byte) ([][]string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(decodeImportText(data)))
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	var (
+		records []map[string]string
+		keys    []string
+	)
+	seen := make(map[string]bool)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			records = append(records, nil)
+			continue
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(line))
+		decoder.UseNumber()
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			return nil, fmt.Errorf("第%d行不是有效的JSON对象: %v", lineNo, err)
+		}
+
+		record := make(map[string]string, len(object))
+		names := make([]string, 0, len(object))
+		for key, value := range object {
+			record[key] = jsonCellValue(value)
+			names = append(names, key)
+		}
+		sort.Strings(names)
+		for _, key := range names {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("JSON Lines 读取失败: %v", err)
+	}
+
+	table := make([][]string, 0, len(records)+1)
+	table = append(table, keys)
+	for _, record := range records {
+		if record == nil {
+			table = append(table, nil)
+			continue
+		}
+		row := make([]string, len(keys))
+		for i, key := range keys {
+			row[i] = record[key]
+		}
+		table = append(table, row)
+	}
+	return table, nil
+}
+
+func jsonCellValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	default:
+		encoded, _ := json.Marshal(v)
+		return string(encoded)
+	}
+}
+
+// splitTableByOrderType 按订单类型拆分表格，其他类型的行以空行占位以保留行号
+func splitTableByOrderType(table [][]string, defaultType string, profile *models.ImportProfile) workbookSheets {
+	sheets := make(workbookSheets)
+	if len(table) == 0 {
+		return sheets
+	}
+
+	typeColumn := -1
+	for i, header := range table[0] {
+		if orderTypeHeaders[strings.ToLower(normalizeHeaderTitle(header))] {
+			typeColumn = i
+			break
+		}
+	}
+
+	groups := map[string][][]string{
+		"platform": make([][]string, len(table)),
+		"factory":  make([][]string, len(table)),
+	}
+	for _, group := range groups {
+		group[0] = table[0]
+	}
+	for i := 1; i < len(table); i++ {
+		orderType := defaultType
+		if typeColumn >= 0 {
+			if value := normalizeImportOrderType(getValue(table[i], typeColumn)); value != "" {
+				orderType = value
+			}
+		}
+		groups[orderType][i] = table[i]
+	}
+
+	for orderType, rows := range groups {
+		hasData := false
+		for _, row := range rows[1:] {
+			if !rowIsEmpty(row) {
+				hasData = true
+				break
+			}
+		}
+		if hasData {
+			sheets[importSheetName(orderType, profile)] = rows
+		}
+	}
+	return sheets
+}
+
+// normalizeImportOrderType 识别订单类型取值，无法识别时返回空
+func normalizeImportOrderType(value string) string {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "platform", "平台", "平台面单":
+		return "platform"
+	case "factory", "工厂", "工厂物流":
+		return "factory"
+	default:
+		return ""
+	}
+}
+
+// importSheetName 文本格式拆分后的工作表名称，需能被导入模板或内置关键词匹配
+func importSheetName(orderType string, profile *models.ImportProfile) string {
+	if mapping := profile.SheetMapping(orderType); mapping != nil && len(mapping.SheetKeywords) > 0 {
+		return mapping.SheetKeywords[0]
+	}
+	return builtinSheetKeywords[orderType][0]
+}
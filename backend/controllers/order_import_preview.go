@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"time"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+const (
+	importActionCreate = "create"
+	importActionUpdate = "update"
+)
+
+// importPreviewSkipFields 预检比对时忽略的订单字段，由系统维护
+var importPreviewSkipFields = map[string]bool{
+	"ID":          true,
+	"CreatedAt":   true,
+	"UpdatedAt":   true,
+	"DeletedAt":   true,
+	"CreatedBy":   true,
+	"UpdatedBy":   true,
+	"Attachments": true,
+}
+
+// orderImportPreview 预检结果，校验错误见任务的 errors 字段
+type orderImportPreview struct {
+	Summary importPreviewSummary `json:"summary"`
+	Rows    []importPreviewRow   `json:"rows"`
+}
+
+type importPreviewSummary struct {
+	TotalRows         int `json:"total_rows"`          // 数据行数
+	CreateCount       int `json:"create_count"`        // 将新增的订单数
+	UpdateCount       int `json:"update_count"`        // 将更新的订单数
+	DuplicateCount    int `json:"duplicate_count"`     // 系统中已有类似记录的行数
+	MaterialLinkCount int `json:"material_link_count"` // 将自动关联素材的行数
+	ErrorCount        int `json:"error_count"`         // 校验错误数
+	ErrorRows         int `json:"error_rows"`          // 存在校验错误的行数
+}
+
+// importPreviewRow 单行的预计处理结果
+type importPreviewRow struct {
+	Sheet           string                 `json:"sheet"`
+	Row             int                    `json:"row"`
+	Action          string                 `json:"action"` // create 新增 / update 更新
+	GSPOrderNo      string                 `json:"gsp_order_no"`
+	OrderType       string                 `json:"order_type"`
+	ExistingOrderID uint64                 `json:"existing_order_id,omitempty"`
+	SameAsSheet     string                 `json:"same_as_sheet,omitempty"` // 与文件中前面某行为同一订单时，指向该行
+	SameAsRow       int                    `json:"same_as_row,omitempty"`
+	Duplicate       bool                   `json:"duplicate"`
+	Changes         []importFieldChange    `json:"changes,omitempty"`
+	Material        *importPreviewMaterial `json:"material,omitempty"`
+}
+
+// importFieldChange 更新时将发生变化的字段
+type importFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// importPreviewMaterial 将自动关联的素材
+type importPreviewMaterial struct {
+	ID            uint64 `json:"id"`
+	Code          string `json:"code"`
+	Title         string `json:"title"`
+	FileName      string `json:"file_name"`
+	AlreadyLinked bool   `json:"already_linked"` // 订单已关联该素材，不会重复写入
+}
+
+// importPreviewState 文件中已出现过的订单在前面各行写入后的状态
+type importPreviewState struct {
+	Order models.OrderInfo
+	Sheet string
+	Row   int
+}
+
+// runOrderImportPreview 执行预检并结束任务，不写入任何订单数据
+func runOrderImportPreview(jobID uint64, rows []importRow, errs []validationError, totalRows, failedRows int, updates map[string]interface{}) {
+	preview, err := buildOrderImportPreview(jobID, rows)
+	if err != nil {
+		finishImportJob(jobID, models.ImportJobFailed, fmt.Sprintf("预检失败: %v", err), errs, updates)
+		return
+	}
+	preview.Summary.TotalRows = totalRows
+	preview.Summary.ErrorCount = len(errs)
+	preview.Summary.ErrorRows = failedRows
+	saveImportJobResult(jobID, preview)
+
+	if updates == nil {
+		updates = make(map[string]interface{})
+	}
+	updates["processed_rows"] = totalRows
+	finishImportJob(jobID, models.ImportJobSucceeded,
+		fmt.Sprintf("预检完成：新增%d条，更新%d条，错误%d处",
+			preview.Summary.CreateCount, preview.Summary.UpdateCount, len(errs)),
+		errs, updates)
+}
+
+// buildOrderImportPreview 按 upsertOrder 与 autoLinkMaterialForOrder 的规则比对现有数据，得出每行的处理结果
+func buildOrderImportPreview(jobID uint64, rows []importRow) (*orderImportPreview, error) {
+	preview := &orderImportPreview{Rows: make([]importPreviewRow, 0, len(rows))}
+	states := make(map[string]*importPreviewState)
+
+	for i, row := range rows {
+		order := row.Order
+		normalizeUpsertOrder(&order)
+
+		item := importPreviewRow{
+			Sheet:      row.Sheet,
+			Row:        row.Row,
+			GSPOrderNo: order.GSPOrderNo,
+			OrderType:  order.OrderType,
+			Duplicate:  row.Duplicate,
+		}
+
+		key := fmt.Sprintf("%s|%s|%d", order.GSPOrderNo, order.OrderType, order.OrderCreatedAt.UnixNano())
+		if state, ok := states[key]; ok {
+			item.Action = importActionUpdate
+			item.ExistingOrderID = state.Order.ID
+			item.SameAsSheet = state.Sheet
+			item.SameAsRow = state.Row
+			item.Changes = mergeImportOrderChanges(&state.Order, order)
+			state.Sheet, state.Row = row.Sheet, row.Row
+		} else {
+			existing, err := findUpsertTarget(database.DB, &order)
+			if err != nil {
+				return nil, fmt.Errorf("%s第%d行: %w", row.Sheet, row.Row, err)
+			}
+			state := &importPreviewState{Order: order, Sheet: row.Sheet, Row: row.Row}
+			if existing == nil {
+				item.Action = importActionCreate
+			} else {
+				item.Action = importActionUpdate
+				item.ExistingOrderID = existing.ID
+				state.Order = *existing
+				item.Changes = mergeImportOrderChanges(&state.Order, order)
+			}
+			states[key] = state
+		}
+
+		material, matchKey, err := findAutoLinkMaterial(database.DB, &order)
+		if err != nil {
+			log.Printf("import preview: query material failed for key=%s: %v", matchKey, err)
+		} else if material != nil {
+			item.Material = &importPreviewMaterial{
+				ID:       material.ID,
+				Code:     material.Code,
+				Title:    material.Title,
+				FileName: material.FileName,
+			}
+			if item.ExistingOrderID != 0 {
+				var count int64
+				if err := database.DB.Model(&models.OrderAttachment{}).
+					Where("order_id = ? AND file_type = ? AND material_id = ?", item.ExistingOrderID, "material_image", material.ID).
+					Count(&count).Error; err != nil {
+					return nil, fmt.Errorf("%s第%d行: %w", row.Sheet, row.Row, err)
+				}
+				item.Material.AlreadyLinked = count > 0
+			}
+			if !item.Material.AlreadyLinked {
+				preview.Summary.MaterialLinkCount++
+			}
+		}
+
+		switch item.Action {
+		case importActionCreate:
+			preview.Summary.CreateCount++
+		case importActionUpdate:
+			preview.Summary.UpdateCount++
+		}
+		if item.Duplicate {
+			preview.Summary.DuplicateCount++
+		}
+		preview.Rows = append(preview.Rows, item)
+
+		if (i+1)%orderImportChunkSize == 0 {
+			updateImportJob(jobID, map[string]interface{}{"processed_rows": i + 1})
+		}
+	}
+	return preview, nil
+}
+
+// mergeImportOrderChanges 比对导入数据与当前订单，返回变化的字段并合并到 target。
+// 与 gorm Updates(struct) 一致，导入数据中的零值字段不会覆盖原值
+func mergeImportOrderChanges(target *models.OrderInfo, incoming models.OrderInfo) []importFieldChange {
+	var changes []importFieldChange
+	targetValue := reflect.ValueOf(target).Elem()
+	incomingValue := reflect.ValueOf(incoming)
+	orderType := targetValue.Type()
+
+	for i := 0; i < orderType.NumField(); i++ {
+		field := orderType.Field(i)
+		if importPreviewSkipFields[field.Name] {
+			continue
+		}
+		value := incomingValue.Field(i)
+		if value.IsZero() {
+			continue
+		}
+		current := targetValue.Field(i)
+		if importFieldEqual(current.Interface(), value.Interface()) {
+			continue
+		}
+		changes = append(changes, importFieldChange{
+			Field: strings.Split(field.Tag.Get("json"), ",")[0],
+			From:  current.Interface(),
+			To:    value.Interface(),
+		})
+		current.Set(value)
+	}
+	return changes
+}
+
+func importFieldEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case time.Time:
+		return av.Equal(b.(time.Time))
+	case *time.Time:
+		bv := b.(*time.Time)
+		if av == nil || bv == nil {
+			return av == bv
+		}
+		return av.Equal(*bv)
+	default:
+		return reflect.DeepEqual(a, b)
+	}
+}
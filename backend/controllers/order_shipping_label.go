@@ -0,0 +1,267 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
+)
+
+// shippingLabelMergeMaxCount 单次合并面单的订单上限，面单在内存中合并
+const shippingLabelMergeMaxCount = 500
+
+// shippingLabelSortNames 面单排序方式
+var shippingLabelSortNames = map[string]string{
+	"warehouse":  "发货仓库",
+	"shop":       "店铺编号",
+	"order_time": "订单创建时间",
+}
+
+// shippingLabelMergeRequest 合并面单请求。ids 为空且 use_filters 为 true 时，
+// 按与订单列表相同的查询参数选取订单
+type shippingLabelMergeRequest struct {
+	IDs        []uint64 `json:"ids"`
+	UseFilters bool     `json:"use_filters"`
+	Sort       string   `json:"sort"` // warehouse（默认）/ shop / order_time
+}
+
+// shippingLabelSummary 封面汇总信息
+type shippingLabelSummary struct {
+	Sort        string
+	OrderCount  int
+	LabelCount  int
+	Denied      int
+	ByWarehouse map[string]int
+	ByShop      map[string]int
+	Missing     []string // 没有面单的订单
+	Failed      []string // 读取或解析失败的面单
+}
+
+// MergeShippingLabels 将所选订单的面单按指定顺序合并为一个 PDF，首页为汇总封面
+func MergeShippingLabels(c *gin.Context) {
+	var req shippingLabelMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Sort == "" {
+		req.Sort = "warehouse"
+	}
+	if _, ok := shippingLabelSortNames[req.Sort]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort 仅支持 warehouse、shop 或 order_time"})
+		return
+	}
+
+	ids, denied, err := resolveBulkOrderIDs(c, &bulkOrderRequest{IDs: req.IDs, UseFilters: req.UseFilters})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "没有可打印面单的订单"})
+		return
+	}
+	if len(ids) > shippingLabelMergeMaxCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("单次最多合并%d条订单的面单，请缩小范围", shippingLabelMergeMaxCount)})
+		return
+	}
+
+	orders, attachments, err := loadShippingLabelOrders(ids)
+	if err != nil {
+		log.Printf("failed to load shipping labels: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "查询面单失败"})
+		return
+	}
+	sortShippingLabelOrders(orders, req.Sort)
+
+	summary := shippingLabelSummary{
+		Sort:        req.Sort,
+		OrderCount:  len(orders),
+		Denied:      len(denied),
+		ByWarehouse: make(map[string]int),
+		ByShop:      make(map[string]int),
+	}
+	files := []io.ReadSeeker{nil} // 首位留给封面
+	for _, order := range orders {
+		labels := attachments[order.ID]
+		if len(labels) == 0 {
+			summary.Missing = append(summary.Missing, shippingLabelOrderName(&order))
+			continue
+		}
+		for _, label := range labels {
+			content, err := readShippingLabel(c, &label)
+			if err != nil {
+				log.Printf("shipping label %d unavailable: %v", label.ID, err)
+				summary.Failed = append(summary.Failed, fmt.Sprintf("%s %s", shippingLabelOrderName(&order), label.FileName))
+				continue
+			}
+			files = append(files, content)
+			summary.LabelCount++
+			summary.ByWarehouse[order.ShippingWarehouseCode]++
+			summary.ByShop[order.ShopCode]++
+		}
+	}
+	if summary.LabelCount == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "所选订单均没有可用的面单"})
+		return
+	}
+
+	files[0] = bytes.NewReader(utils.BuildTextPDF("面单合并打印", buildShippingLabelCover(&summary)))
+	var merged bytes.Buffer
+	if err := utils.MergePDFs(files, &merged); err != nil {
+		log.Printf("failed to merge shipping labels: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "合并面单失败"})
+		return
+	}
+
+	utils.LogAction(currentUserID(c), c.GetString("username"), "合并面单", "订单",
+		fmt.Sprintf("合并面单：订单%d条，面单%d份，缺少面单%d条", summary.OrderCount, summary.LabelCount, len(summary.Missing)),
+		utils.GetClientIP(c.Request), c.Request.UserAgent(), 1)
+
+	filename := fmt.Sprintf("shipping_labels_%d.pdf", time.Now().Unix())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, "application/pdf", merged.Bytes())
+}
+
+// loadShippingLabelOrders 分批读取订单及其面单附件
+func loadShippingLabelOrders(ids []uint64) ([]models.OrderInfo, map[uint64][]models.OrderAttachment, error) {
+	orders := make([]models.OrderInfo, 0, len(ids))
+	attachments := make(map[uint64][]models.OrderAttachment)
+	for start := 0; start < len(ids); start += bulkOrderChunkSize {
+		end := start + bulkOrderChunkSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		var batch []models.OrderInfo
+		if err := database.DB.Where("id IN ?", ids[start:end]).Find(&batch).Error; err != nil {
+			return nil, nil, err
+		}
+		orders = append(orders, batch...)
+
+		var labels []models.OrderAttachment
+		if err := database.DB.Where("order_id IN ? AND file_type = ?", ids[start:end], "shipping_label").
+			Order("id").Find(&labels).Error; err != nil {
+			return nil, nil, err
+		}
+		for _, label := range labels {
+			attachments[label.OrderID] = append(attachments[label.OrderID], label)
+		}
+	}
+	return orders, attachments, nil
+}
+
+// sortShippingLabelOrders 按发货仓库、店铺或订单创建时间排序，其余字段依次作为次要排序
+func sortShippingLabelOrders(orders []models.OrderInfo, sortBy string) {
+	sort.SliceStable(orders, func(i, j int) bool {
+		a, b := &orders[i], &orders[j]
+		switch sortBy {
+		case "warehouse":
+			if a.ShippingWarehouseCode != b.ShippingWarehouseCode {
+				return a.ShippingWarehouseCode < b.ShippingWarehouseCode
+			}
+			if a.ShopCode != b.ShopCode {
+				return a.ShopCode < b.ShopCode
+			}
+		case "shop":
+			if a.ShopCode != b.ShopCode {
+				return a.ShopCode < b.ShopCode
+			}
+			if a.ShippingWarehouseCode != b.ShippingWarehouseCode {
+				return a.ShippingWarehouseCode < b.ShippingWarehouseCode
+			}
+		}
+		if !a.OrderCreatedAt.Equal(b.OrderCreatedAt) {
+			return a.OrderCreatedAt.Before(b.OrderCreatedAt)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// readShippingLabel 从存储读取面单并校验是否为可解析的 PDF
+func readShippingLabel(c *gin.Context, label *models.OrderAttachment) (io.ReadSeeker, error) {
+	reader, err := utils.OpenAttachment(c.Request.Context(), label.Storage, label.FilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	content := bytes.NewReader(data)
+	if err := utils.ValidatePDF(content); err != nil {
+		return nil, err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func shippingLabelOrderName(order *models.OrderInfo) string {
+	if order.GSPOrderNo != "" {
+		return order.GSPOrderNo
+	}
+	return fmt.Sprintf("订单%d", order.ID)
+}
+
+// buildShippingLabelCover 生成封面的汇总内容
+func buildShippingLabelCover(summary *shippingLabelSummary) []string {
+	lines := []string{
+		"生成时间：" + time.Now().Format("2006-01-02 15:04:05"),
+		"排序方式：按" + shippingLabelSortNames[summary.Sort],
+		fmt.Sprintf("订单数：%d    面单数：%d    缺少面单：%d    读取失败：%d",
+			summary.OrderCount, summary.LabelCount, len(summary.Missing), len(summary.Failed)),
+	}
+	if summary.Denied > 0 {
+		lines = append(lines, fmt.Sprintf("无权访问或不存在的订单：%d", summary.Denied))
+	}
+
+	lines = append(lines, "", "按发货仓库：")
+	lines = append(lines, shippingLabelCountLines(summary.ByWarehouse)...)
+	lines = append(lines, "", "按店铺：")
+	lines = append(lines, shippingLabelCountLines(summary.ByShop)...)
+
+	if len(summary.Missing) > 0 {
+		lines = append(lines, "", "缺少面单的订单：")
+		for _, name := range summary.Missing {
+			lines = append(lines, "    "+name)
+		}
+	}
+	if len(summary.Failed) > 0 {
+		lines = append(lines, "", "读取失败的面单：")
+		for _, name := range summary.Failed {
+			lines = append(lines, "    "+name)
+		}
+	}
+	return lines
+}
+
+func shippingLabelCountLines(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		name := strings.TrimSpace(key)
+		if name == "" {
+			name = "未设置"
+		}
+		lines = append(lines, fmt.Sprintf("    %s：%d 份", name, counts[key]))
+	}
+	return lines
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+// orderTransitionPath 状态流转接口的路由（已登记为资源），编辑订单修改状态时按该接口的资源权限校验
+const orderTransitionPath = "/api/orders/:id/transition"
+
+// errOrderStatusChanged 流转过程中订单状态已被其他请求修改
+var errOrderStatusChanged = errors.New("订单状态已被修改，请刷新后重试")
+
+// orderTransitionError 不允许的状态流转
+type orderTransitionError struct {
+	From int8
+	To   int8
+}
+
+func (e *orderTransitionError) Error() string {
+	return fmt.Sprintf("订单状态不允许从「%s」变更为「%s」",
+		models.OrderStatusName(e.From), models.OrderStatusName(e.To))
+}
+
+type orderTransitionRequest struct {
+	Status *int8  `json:"status" binding:"required"`
+	Remark string `json:"remark"`
+}
+
+// orderStatusOptions 将状态列表转换为前端可用的选项
+func orderStatusOptions(statuses []int8) []gin.H {
+	options := make([]gin.H, 0, len(statuses))
+	for _, status := range statuses {
+		options = append(options, gin.H{"value": status, "label": models.OrderStatusName(status)})
+	}
+	return options
+}
+
+// transitionOrderStatus 在事务内校验并执行订单状态流转，同时写入流转记录
+func transitionOrderStatus(tx *gorm.DB, order *models.OrderInfo, to int8, operatorID uint, operatorName, remark string) error {
+	from := order.Status
+	if !models.IsValidOrderStatus(to) || !models.CanTransitionOrderStatus(from, to) {
+		return &orderTransitionError{From: from, To: to}
+	}
+
+	updates := map[string]interface{}{
+		"status":     to,
+		"updated_by": uint64(operatorID),
+	}
+	var completedAt *time.Time
+	if to == models.OrderStatusCompleted {
+		now := time.Now()
+		completedAt = &now
+		updates["completed_at"] = now
+	}
+
+	result := tx.Model(&models.OrderInfo{}).
+		Where("id = ? AND status = ?", order.ID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errOrderStatusChanged
+	}
+
+	history := models.OrderStatusHistory{
+		OrderID:      order.ID,
+		FromStatus:   from,
+		ToStatus:     to,
+		OperatorID:   operatorID,
+		OperatorName: operatorName,
+		Remark:       strings.TrimSpace(remark),
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+
+	order.Status = to
+	order.UpdatedBy = uint64(operatorID)
+	if completedAt != nil {
+		order.CompletedAt = completedAt
+	}
+	return nil
+}
+
+// TransitionOrder 按状态机流转订单状态，非法流转返回 400
+func TransitionOrder(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "订单ID格式错误"})
+		return
+	}
+
+	var req orderTransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var order models.OrderInfo
+	if err := database.DB.Where("id = ?", orderID).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "订单不存在"})
+		return
+	}
+	if !canAccessCreatedBy(c, order.CreatedBy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该订单"})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		return transitionOrderStatus(tx, &order, *req.Status, currentUserID(c), c.GetString("username"), req.Remark)
+	})
+	if err != nil {
+		var transitionErr *orderTransitionError
+		switch {
+		case errors.As(err, &transitionErr):
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":         err.Error(),
+				"next_statuses": orderStatusOptions(models.NextOrderStatuses(order.Status)),
+			})
+		case errors.Is(err, errOrderStatusChanged):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "更新订单状态失败"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":          order,
+		"next_statuses": orderStatusOptions(models.NextOrderStatuses(order.Status)),
+	})
+}
+
+// GetOrderStatusHistory 获取订单状态流转记录
+func GetOrderStatusHistory(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "订单ID格式错误"})
+		return
+	}
+
+	var order models.OrderInfo
+	if err := database.DB.Select("id", "status", "created_by").Where("id = ?", orderID).First(&order).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "订单不存在"})
+		return
+	}
+	if !canAccessCreatedBy(c, order.CreatedBy) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "无权访问该订单"})
+		return
+	}
+
+	var histories []models.OrderStatusHistory
+	if err := database.DB.Where("order_id = ?", orderID).Order("id DESC").Find(&histories).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取状态记录失败"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":          histories,
+		"status":        order.Status,
+		"next_statuses": orderStatusOptions(models.NextOrderStatuses(order.Status)),
+	})
+}
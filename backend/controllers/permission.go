@@ -3,6 +3,7 @@ package controllers
 import (
 	"haodun_manage/backend/database"
 	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
 	"net/http"
 	"strconv"
 
@@ -66,12 +67,14 @@ func UpdatePermission(c *gin.Context) {
 	}
 
 	database.DB.Save(&permission)
+	utils.InvalidateAllPermissionCache()
 	c.JSON(http.StatusOK, permission)
 }
 
 func DeletePermission(c *gin.Context) {
 	id := c.Param("id")
 	database.DB.Delete(&models.Permission{}, id)
+	utils.InvalidateAllPermissionCache()
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
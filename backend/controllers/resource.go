@@ -3,6 +3,7 @@ package controllers
 import (
 	"haodun_manage/backend/database"
 	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
 	"net/http"
 	"strconv"
 
@@ -94,6 +95,7 @@ func CreateResource(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "创建资源失败"})
 		return
 	}
+	utils.InvalidateAllPermissionCache()
 
 	// 重新加载关联数据
 	database.DB.Preload("Parent").First(&resource, resource.ID)
@@ -164,7 +166,8 @@ func UpdateResource(c *gin.Context) {
 	resource.Icon = updateData.Icon
 
 	database.DB.Save(&resource)
-	
+	utils.InvalidateAllPermissionCache()
+
 	// 重新加载关联数据
 	database.DB.Preload("Parent").First(&resource, resource.ID)
 	c.JSON(http.StatusOK, resource)
@@ -187,6 +190,7 @@ func DeleteResource(c *gin.Context) {
 	}
 
 	database.DB.Delete(&resource)
+	utils.InvalidateAllPermissionCache()
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
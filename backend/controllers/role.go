@@ -3,6 +3,7 @@ package controllers
 import (
 	"haodun_manage/backend/database"
 	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
 	"net/http"
 	"strconv"
 
@@ -43,6 +44,7 @@ func CreateRole(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	role.DataScope = models.NormalizeDataScope(role.DataScope)
 
 	if err := database.DB.Create(&role).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "创建角色失败"})
@@ -64,14 +66,19 @@ func UpdateRole(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	role.DataScope = models.NormalizeDataScope(role.DataScope)
 
 	database.DB.Save(&role)
+	utils.InvalidateRolePermissionCache(role.ID)
 	c.JSON(http.StatusOK, role)
 }
 
 func DeleteRole(c *gin.Context) {
 	id := c.Param("id")
 	database.DB.Delete(&models.Role{}, id)
+	if roleID, err := strconv.ParseUint(id, 10, 64); err == nil {
+		utils.InvalidateRolePermissionCache(uint(roleID))
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
@@ -94,6 +101,7 @@ func AssignPermissions(c *gin.Context) {
 	var permissions []models.Permission
 	database.DB.Where("id IN ?", req.PermissionIDs).Find(&permissions)
 	database.DB.Model(&role).Association("Permissions").Replace(permissions)
+	utils.InvalidateRolePermissionCache(role.ID)
 
 	c.JSON(http.StatusOK, gin.H{"message": "权限分配成功"})
 }
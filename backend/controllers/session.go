@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"haodun_manage/backend/utils"
+)
+
+func currentSessionID(c *gin.Context) string {
+	value, _ := c.Get("token_claims")
+	if claims, ok := value.(*utils.Claims); ok {
+		return claims.FamilyID
+	}
+	return ""
+}
+
+func markCurrentSession(c *gin.Context, sessions []utils.Session) []utils.Session {
+	currentID := currentSessionID(c)
+	for i := range sessions {
+		sessions[i].Current = currentID != "" && sessions[i].ID == currentID
+	}
+	return sessions
+}
+
+// ListMySessions 获取当前用户的登录会话
+func ListMySessions(c *gin.Context) {
+	sessions := utils.ListUserSessions(currentUserID(c))
+	c.JSON(http.StatusOK, gin.H{"data": markCurrentSession(c, sessions)})
+}
+
+// DeleteMySession 终止当前用户的某个会话，该会话的令牌立即失效
+func DeleteMySession(c *gin.Context) {
+	sessionID := c.Param("id")
+	session, ok := utils.GetSession(sessionID)
+	if !ok || session.UserID != currentUserID(c) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在"})
+		return
+	}
+
+	terminateSession(c, session)
+}
+
+// ListSessions 管理员查看所有用户的登录会话
+func ListSessions(c *gin.Context) {
+	var userID uint
+	if value := c.Query("user_id"); value != "" {
+		if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+			userID = uint(id)
+		}
+	}
+
+	sessions := utils.ListAllSessions(userID)
+	c.JSON(http.StatusOK, gin.H{
+		"data":  markCurrentSession(c, sessions),
+		"total": len(sessions),
+	})
+}
+
+// DeleteSession 管理员终止任意用户的会话
+func DeleteSession(c *gin.Context) {
+	session, ok := utils.GetSession(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "会话不存在"})
+		return
+	}
+
+	terminateSession(c, session)
+}
+
+func terminateSession(c *gin.Context, session *utils.Session) {
+	if err := utils.RevokeTokenFamily(session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "终止会话失败"})
+		return
+	}
+
+	operator, _ := c.Get("username")
+	operatorName, _ := operator.(string)
+	utils.LogAction(currentUserID(c), operatorName, "终止会话", "认证", "终止用户 "+session.Username+" 的会话", utils.GetClientIP(c.Request), c.Request.UserAgent(), 1)
+
+	c.JSON(http.StatusOK, gin.H{"message": "会话已终止"})
+}
@@ -1,70 +1,104 @@
 package controllers
 
 import (
+	"context"
+	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"haodun_manage/backend/models"
 	"haodun_manage/backend/services"
+	"haodun_manage/backend/utils"
 )
 
-type SheinController struct {
-	sheinService *services.SheinService
-}
+// SheinController Shein 接口，按 shop_code 使用对应店铺的凭证
+type SheinController struct{}
 
 func NewSheinController() *SheinController {
-	return &SheinController{
-		sheinService: services.NewSheinService(),
-	}
+	return &SheinController{}
 }
 
 // SyncOrders 同步Shein订单接口
 // @Summary 同步Shein订单
-// @Description 获取指定时间范围内的全部Shein订单（含详情）
+// @Description 获取指定时间范围内的全部Shein订单（含详情），按订单号写入平台面单并自动关联素材
 // @Tags Shein
 // @Accept json
 // @Produce json
 // @Param start_time query string true "开始时间 (格式: 2006-01-02 15:04:05)"
 // @Param end_time query string true "结束时间 (格式: 2006-01-02 15:04:05)"
 // @Param status query string false "订单状态"
-// @Success 200 {object} gin.H{"data": []services.OrderDetailResponse}
+// @Param shop_code query string true "店铺编号，使用该店铺的凭证并写入订单"
+// @Success 200 {object} gin.H{"summary": sheinSyncSummary}
 // @Failure 400 {object} gin.H{"error": string}
+// @Failure 409 {object} gin.H{"error": string}
 // @Failure 500 {object} gin.H{"error": string}
-// @Router /api/shein/sync-orders [get]
+// @Router /api/shein/sync-orders [post]
 func (c *SheinController) SyncOrders(ctx *gin.Context) {
-	startTime := ctx.Query("start_time")
-	endTime := ctx.Query("end_time")
-	status := ctx.Query("status")
+	startTime := requestValue(ctx, "start_time")
+	endTime := requestValue(ctx, "end_time")
+	status := requestValue(ctx, "status")
+	shopCode := requestValue(ctx, "shop_code")
 
 	if startTime == "" || endTime == "" {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "start_time 和 end_time 参数必填"})
 		return
 	}
 
+	service, err := services.ResolveSheinService(shopCode)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 验证时间格式
-	_, err := time.Parse("2006-01-02 15:04:05", startTime)
+	start, err := time.ParseInLocation(services.SheinTimeLayout, startTime, time.Local)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "start_time 格式错误，应为 2006-01-02 15:04:05"})
 		return
 	}
 
-	_, err = time.Parse("2006-01-02 15:04:05", endTime)
+	end, err := time.ParseInLocation(services.SheinTimeLayout, endTime, time.Local)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "end_time 格式错误，应为 2006-01-02 15:04:05"})
 		return
 	}
 
-	// 同步订单
-	orders, err := c.sheinService.SyncOrders(startTime, endTime, status)
+	// 与增量同步共用分布式锁，避免同时写入同一批订单
+	lock, err := utils.TryLock(context.Background(), sheinSyncLockName, sheinSyncLockTTL)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "检查同步状态失败"})
+		return
+	}
+	if lock == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": errSheinSyncRunning.Error()})
 		return
 	}
+	defer func() {
+		if err := lock.Release(context.Background()); err != nil {
+			log.Printf("failed to release shein sync lock: %v", err)
+		}
+	}()
+
+	// 同步订单，手动指定时间范围的同步不推进增量水位
+	run, summary := runSheinSyncWindow(ctx.Request.Context(), service, models.SheinSyncTriggerManual,
+		start, end, status, currentUserID(ctx), nil)
+	if summary == nil {
+		utils.LogAction(currentUserID(ctx), ctx.GetString("username"), "同步Shein订单", "订单", run.Message,
+			utils.GetClientIP(ctx.Request), ctx.Request.UserAgent(), 0)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": run.Message, "run_id": run.ID})
+		return
+	}
+
+	message := "同步完成：" + run.Message
+	utils.LogAction(currentUserID(ctx), ctx.GetString("username"), "同步Shein订单", "订单", message,
+		utils.GetClientIP(ctx.Request), ctx.Request.UserAgent(), 1)
 
 	ctx.JSON(http.StatusOK, gin.H{
-		"data":    orders,
-		"count":   len(orders),
-		"message": "同步成功",
+		"count":   summary.Total,
+		"summary": summary,
+		"message": message,
+		"run_id":  run.ID,
 	})
 }
 
@@ -77,6 +111,7 @@ func (c *SheinController) SyncOrders(ctx *gin.Context) {
 // @Param start_time query string true "开始时间"
 // @Param end_time query string true "结束时间"
 // @Param status query string false "订单状态"
+// @Param shop_code query string true "店铺编号"
 // @Success 200 {object} gin.H{"data": []string}
 // @Router /api/shein/order-list [get]
 func (c *SheinController) GetOrderList(ctx *gin.Context) {
@@ -89,7 +124,13 @@ func (c *SheinController) GetOrderList(ctx *gin.Context) {
 		return
 	}
 
-	orderNos, err := c.sheinService.GetAllOrders(startTime, endTime, status)
+	service, err := services.ResolveSheinService(ctx.Query("shop_code"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderNos, err := service.GetAllOrders(ctx.Request.Context(), startTime, endTime, status)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -108,6 +149,7 @@ func (c *SheinController) GetOrderList(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param order_no query string true "订单号"
+// @Param shop_code query string true "店铺编号"
 // @Success 200 {object} services.OrderDetailResponse
 // @Router /api/shein/order-detail [get]
 func (c *SheinController) GetOrderDetail(ctx *gin.Context) {
@@ -117,9 +159,15 @@ func (c *SheinController) GetOrderDetail(ctx *gin.Context) {
 		return
 	}
 
-	detail, err := c.sheinService.GetOrderDetails([]string{orderNo})
+	service, err := services.ResolveSheinService(ctx.Query("shop_code"))
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	detail, failures := service.GetOrderDetails(ctx.Request.Context(), []string{orderNo})
+	if len(failures) > 0 {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": failures[0].Err.Error()})
 		return
 	}
 
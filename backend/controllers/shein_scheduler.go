@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"haodun_manage/backend/config"
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/services"
+	"haodun_manage/backend/utils"
+)
+
+const (
+	sheinSyncLockName = "shein:order-sync"
+	sheinSyncLockTTL  = 30 * time.Minute
+	// sheinSyncMaxAttempts 单个订单连续失败达到该次数后不再自动重试
+	sheinSyncMaxAttempts = 5
+)
+
+var errSheinSyncRunning = errors.New("Shein 订单同步正在进行中，请稍后再试")
+
+// sheinSyncError 同步记录中保存失败的订单
+type sheinSyncError struct {
+	OrderNo string `json:"order_no"`
+	Message string `json:"message"`
+}
+
+// StartSheinSyncScheduler 启动 Shein 订单定时增量同步，未开启 SHEIN_SYNC_ENABLED 时不启动。
+// 多个实例同时运行时通过 Redis 锁保证同一时间只有一个实例在同步
+func StartSheinSyncScheduler() {
+	if config.AppConfig == nil || !config.AppConfig.SheinSyncEnabled {
+		return
+	}
+	interval := time.Duration(config.AppConfig.SheinSyncMinutes) * time.Minute
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := runSheinSyncRecovered(models.SheinSyncTriggerSchedule, 0); err != nil {
+				if errors.Is(err, errSheinSyncRunning) {
+					log.Printf("shein scheduled sync skipped: another instance is syncing")
+				} else {
+					log.Printf("shein scheduled sync failed: %v", err)
+				}
+			}
+			<-ticker.C
+		}
+	}()
+	log.Printf("shein order sync scheduler started, interval=%s", interval)
+}
+
+// runSheinSyncRecovered 执行一次增量同步并捕获 panic，避免后台任务崩溃导致进程退出或定时任务停止；
+// panic 时将本次仍处于同步中的记录标记为失败
+func runSheinSyncRecovered(trigger string, operatorID uint) (runs []models.SheinSyncRun, err error) {
+	startedAt := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("shein sync panic: %v", r)
+			err = fmt.Errorf("shein sync panic: %v", r)
+			finishedAt := time.Now()
+			if dbErr := database.DB.Model(&models.SheinSyncRun{}).
+				Where("status = ? AND started_at >= ?", models.SheinSyncRunning, startedAt).
+				Updates(map[string]interface{}{
+					"status":      models.SheinSyncFailed,
+					"message":     truncateMessage(fmt.Sprintf("同步异常: %v", r), 512),
+					"finished_at": finishedAt,
+				}).Error; dbErr != nil {
+				log.Printf("failed to mark shein sync runs failed: %v", dbErr)
+			}
+		}
+	}()
+	return runIncrementalSheinSync(trigger, operatorID)
+}
+
+// runIncrementalSheinSync 获取分布式锁后依次同步各店铺，返回本次产生的同步记录。
+// 整个同步不超过锁的有效期，超时后未完成的请求会被取消
+func runIncrementalSheinSync(trigger string, operatorID uint) ([]models.SheinSyncRun, error) {
+	lockCtx := context.Background()
+	lock, err := utils.TryLock(lockCtx, sheinSyncLockName, sheinSyncLockTTL)
+	if err != nil {
+		return nil, err
+	}
+	if lock == nil {
+		return nil, errSheinSyncRunning
+	}
+	defer func() {
+		if err := lock.Release(lockCtx); err != nil {
+			log.Printf("failed to release shein sync lock: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(lockCtx, sheinSyncLockTTL)
+	defer cancel()
+
+	credentials, err := sheinSyncShops()
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []models.SheinSyncRun
+	for i := range credentials {
+		// 单个店铺凭证无效时跳过该店铺，不影响其他店铺
+		service, err := services.NewSheinServiceFromCredential(&credentials[i])
+		if err != nil {
+			log.Printf("shein sync skipped shop %q: %v", credentials[i].ShopCode, err)
+			continue
+		}
+		run, err := syncSheinShop(ctx, service, trigger, operatorID)
+		if err != nil {
+			return runs, err
+		}
+		runs = append(runs, *run)
+
+		// 每个店铺同步后续期，锁已丢失时停止，避免与其他实例并发同步
+		if ok, err := lock.Extend(lockCtx, sheinSyncLockTTL); err != nil || !ok {
+			return runs, fmt.Errorf("shein sync lock lost: %v", err)
+		}
+	}
+	return runs, nil
+}
+
+// sheinSyncShops 需要同步的店铺：已启用凭证的全部店铺
+func sheinSyncShops() ([]models.ShopCredential, error) {
+	var credentials []models.ShopCredential
+	err := database.DB.Where("enabled = ?", true).Order("shop_code").Find(&credentials).Error
+	return credentials, err
+}
+
+// syncSheinShop 从店铺水位减去重叠窗口处同步到当前时间；首次同步回溯 SHEIN_SYNC_LOOKBACK_HOURS。
+// 订单列表拉取成功即推进水位，个别订单的失败记入失败表，在之后的同步中按订单号单独重试
+func syncSheinShop(ctx context.Context, service *services.SheinService, trigger string, operatorID uint) (*models.SheinSyncRun, error) {
+	shopCode := service.ShopCode
+	var state models.SheinSyncState
+	if err := database.DB.Where(models.SheinSyncState{ShopCode: shopCode}).FirstOrCreate(&state).Error; err != nil {
+		return nil, fmt.Errorf("load shein sync state for shop %q: %w", shopCode, err)
+	}
+
+	end := time.Now().Truncate(time.Second)
+	start := end.Add(-time.Duration(config.AppConfig.SheinLookbackHrs) * time.Hour)
+	if state.Watermark != nil {
+		start = state.Watermark.Add(-time.Duration(config.AppConfig.SheinOverlapMins) * time.Minute)
+	}
+
+	var retryOrderNos []string
+	if err := database.DB.Model(&models.SheinSyncFailure{}).
+		Where("shop_code = ? AND dead = ?", shopCode, false).
+		Order("id").Pluck("order_no", &retryOrderNos).Error; err != nil {
+		log.Printf("failed to load shein sync failures for shop %q: %v", shopCode, err)
+	}
+
+	run, summary := runSheinSyncWindow(ctx, service, trigger, start, end, "", operatorID, retryOrderNos)
+
+	updates := map[string]interface{}{"last_run_id": run.ID}
+	if summary != nil {
+		updates["watermark"] = end
+		recordSheinSyncFailures(shopCode, run.ID, summary)
+	}
+	if err := database.DB.Model(&state).Updates(updates).Error; err != nil {
+		log.Printf("failed to update shein sync state for shop %q: %v", shopCode, err)
+	}
+	return run, nil
+}
+
+// recordSheinSyncFailures 更新失败表：失败的订单累加次数，达到上限后标记为 dead；处理成功或跳过的订单移出失败表
+func recordSheinSyncFailures(shopCode string, runID uint64, summary *sheinSyncSummary) {
+	for _, result := range summary.Results {
+		if result.Action != sheinSyncFailed {
+			if err := database.DB.Where("shop_code = ? AND order_no = ?", shopCode, result.OrderNo).
+				Delete(&models.SheinSyncFailure{}).Error; err != nil {
+				log.Printf("failed to clear shein sync failure %s: %v", result.OrderNo, err)
+			}
+			continue
+		}
+
+		var failure models.SheinSyncFailure
+		err := database.DB.Where(models.SheinSyncFailure{ShopCode: shopCode, OrderNo: result.OrderNo}).
+			FirstOrInit(&failure).Error
+		if err != nil {
+			log.Printf("failed to load shein sync failure %s: %v", result.OrderNo, err)
+			continue
+		}
+		failure.Attempts++
+		failure.Dead = failure.Attempts >= sheinSyncMaxAttempts
+		failure.Message = result.Message
+		failure.LastRunID = runID
+		if err := database.DB.Save(&failure).Error; err != nil {
+			log.Printf("failed to save shein sync failure %s: %v", result.OrderNo, err)
+		}
+	}
+}
+
+// runSheinSyncWindow 拉取并保存指定时间范围内的订单，执行过程记录为一条同步记录；
+// retryOrderNos 中不在本次范围内的订单单独获取详情后一并保存。拉取失败时 summary 为 nil
+func runSheinSyncWindow(ctx context.Context, service *services.SheinService, trigger string, start, end time.Time, status string, operatorID uint, retryOrderNos []string) (*models.SheinSyncRun, *sheinSyncSummary) {
+	run := models.SheinSyncRun{
+		ShopCode:    service.ShopCode,
+		Trigger:     trigger,
+		Status:      models.SheinSyncRunning,
+		WindowStart: start,
+		WindowEnd:   end,
+		CreatedBy:   operatorID,
+		StartedAt:   time.Now(),
+	}
+	if err := database.DB.Create(&run).Error; err != nil {
+		log.Printf("failed to create shein sync run: %v", err)
+	}
+
+	var summary *sheinSyncSummary
+	details, fetchFailures, err := service.SyncOrders(ctx, start.Format(services.SheinTimeLayout), end.Format(services.SheinTimeLayout), status)
+	if err != nil {
+		run.Status = models.SheinSyncFailed
+		run.Message = truncateMessage(err.Error(), 512)
+	} else {
+		if missing := missingSheinOrders(retryOrderNos, details, fetchFailures); len(missing) > 0 {
+			retried, retryFailures := service.GetOrderDetails(ctx, missing)
+			details = append(details, retried...)
+			fetchFailures = append(fetchFailures, retryFailures...)
+		}
+		summary = saveSheinOrders(ctx, details, service.ShopCode, operatorID)
+		// 获取详情失败的订单同样计为失败，记入失败表后重试
+		for _, failure := range fetchFailures {
+			summary.Total++
+			summary.add(sheinSyncResult{
+				OrderNo: failure.OrderNo,
+				Action:  sheinSyncFailed,
+				Message: truncateMessage("获取订单详情失败: "+failure.Err.Error(), 200),
+			})
+		}
+		run.Total = summary.Total
+		run.Created = summary.Created
+		run.Updated = summary.Updated
+		run.Skipped = summary.Skipped
+		run.Failed = summary.Failed
+		run.Message = fmt.Sprintf("新增%d条，更新%d条，跳过%d条，失败%d条",
+			summary.Created, summary.Updated, summary.Skipped, summary.Failed)
+		run.Status = models.SheinSyncSucceeded
+		if summary.Failed > 0 {
+			run.Status = models.SheinSyncPartial
+			var failures []sheinSyncError
+			for _, result := range summary.Results {
+				if result.Action == sheinSyncFailed {
+					failures = append(failures, sheinSyncError{OrderNo: result.OrderNo, Message: result.Message})
+				}
+			}
+			if data, err := json.Marshal(failures); err == nil {
+				run.Errors = string(data)
+			}
+		}
+	}
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	if err := database.DB.Save(&run).Error; err != nil {
+		log.Printf("failed to save shein sync run: %v", err)
+	}
+	return &run, summary
+}
+
+// missingSheinOrders 返回 orderNos 中未出现在本次拉取结果里的订单号
+func missingSheinOrders(orderNos []string, details []services.OrderDetailResponse, failures []services.OrderFetchError) []string {
+	if len(orderNos) == 0 {
+		return nil
+	}
+	fetched := make(map[string]struct{}, len(details)+len(failures))
+	for _, detail := range details {
+		fetched[detail.Data.OrderNo] = struct{}{}
+	}
+	for _, failure := range failures {
+		fetched[failure.OrderNo] = struct{}{}
+	}
+	var missing []string
+	for _, orderNo := range orderNos {
+		if _, ok := fetched[orderNo]; !ok {
+			missing = append(missing, orderNo)
+		}
+	}
+	return missing
+}
+
+func truncateMessage(message string, limit int) string {
+	runes := []rune(message)
+	if len(runes) <= limit {
+		return message
+	}
+	return string(runes[:limit])
+}
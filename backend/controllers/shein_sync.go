@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/services"
+)
+
+// Shein 订单同步的处理结果
+const (
+	sheinSyncCreated = "created"
+	sheinSyncUpdated = "updated"
+	sheinSyncSkipped = "skipped"
+	sheinSyncFailed  = "failed"
+)
+
+// sheinSyncSummary 一次同步的汇总
+type sheinSyncSummary struct {
+	Total   int               `json:"total"`
+	Created int               `json:"created"`
+	Updated int               `json:"updated"`
+	Skipped int               `json:"skipped"`
+	Failed  int               `json:"failed"`
+	Results []sheinSyncResult `json:"results"`
+}
+
+// sheinSyncResult 单个 Shein 订单的处理结果
+type sheinSyncResult struct {
+	OrderNo string `json:"order_no"`
+	Action  string `json:"action"` // created / updated / skipped / failed
+	OrderID uint64 `json:"order_id,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func (s *sheinSyncSummary) add(result sheinSyncResult) {
+	switch result.Action {
+	case sheinSyncCreated:
+		s.Created++
+	case sheinSyncUpdated:
+		s.Updated++
+	case sheinSyncSkipped:
+		s.Skipped++
+	case sheinSyncFailed:
+		s.Failed++
+	}
+	s.Results = append(s.Results, result)
+}
+
+// saveSheinOrders 将同步到的订单写入平台面单，每个订单单独提交，单个订单失败不影响其他订单
+func saveSheinOrders(ctx context.Context, details []services.OrderDetailResponse, shopCode string, operatorID uint) *sheinSyncSummary {
+	summary := &sheinSyncSummary{Total: len(details), Results: make([]sheinSyncResult, 0, len(details))}
+	for i := range details {
+		summary.add(saveSheinOrder(ctx, &details[i], shopCode, operatorID))
+	}
+	return summary
+}
+
+// saveSheinOrder 按 upsertOrder 的规则写入单个订单，以 Shein 订单号去重：
+// 已存在时沿用原订单创建时间以命中同一条记录，内容无变化时跳过；写入后自动关联素材
+func saveSheinOrder(ctx context.Context, detail *services.OrderDetailResponse, shopCode string, operatorID uint) sheinSyncResult {
+	result := sheinSyncResult{OrderNo: detail.Data.OrderNo}
+	order, err := detail.ToOrderInfo()
+	if err != nil {
+		// 映射失败按失败处理，进入失败表重试，避免同步窗口推进后订单丢失
+		result.Action = sheinSyncFailed
+		result.Message = err.Error()
+		return result
+	}
+	order.ShopCode = shopCode
+	userID := uint64(operatorID)
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var existing models.OrderInfo
+		err := tx.Where("gsp_order_no = ? AND order_type = ?", order.GSPOrderNo, order.OrderType).
+			Order("id DESC").First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			order.CreatedBy = userID
+			order.UpdatedBy = userID
+			if err := upsertOrder(tx, order); err != nil {
+				return err
+			}
+			result.Action = sheinSyncCreated
+		case err != nil:
+			return err
+		default:
+			order.OrderCreatedAt = existing.OrderCreatedAt
+			current := existing
+			if len(mergeImportOrderChanges(&current, *order)) == 0 {
+				order.ID = existing.ID
+				result.Action = sheinSyncSkipped
+				result.Message = "订单无变化"
+				break
+			}
+			order.UpdatedBy = userID
+			if err := upsertOrder(tx, order); err != nil {
+				return err
+			}
+			result.Action = sheinSyncUpdated
+		}
+		result.OrderID = order.ID
+
+		if err := autoLinkMaterialForOrder(ctx, tx, order, operatorID); err != nil {
+			log.Printf("auto link material failed for shein order %s: %v", order.GSPOrderNo, err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("failed to save shein order %s: %v", result.OrderNo, err)
+		result.Action = sheinSyncFailed
+		result.OrderID = 0
+		result.Message = "保存订单失败"
+	}
+	return result
+}
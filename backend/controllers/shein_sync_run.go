@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
+)
+
+// GetSyncRuns 获取 Shein 订单同步记录
+// @Summary 获取Shein同步记录
+// @Tags Shein
+// @Produce json
+// @Param shop_code query string false "店铺编号"
+// @Param status query string false "状态 running/succeeded/partial/failed"
+// @Param trigger query string false "触发方式 schedule/manual"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Router /api/shein/sync-runs [get]
+func (c *SheinController) GetSyncRuns(ctx *gin.Context) {
+	var runs []models.SheinSyncRun
+	page, _ := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(ctx.DefaultQuery("page_size", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 10
+	}
+	offset := (page - 1) * pageSize
+
+	query := database.DB.Model(&models.SheinSyncRun{})
+	if shopCode := ctx.Query("shop_code"); shopCode != "" {
+		query = query.Where("shop_code = ?", shopCode)
+	}
+	if status := ctx.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if trigger := ctx.Query("trigger"); trigger != "" {
+		query = query.Where("trigger_type = ?", trigger)
+	}
+
+	var total int64
+	query.Count(&total)
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&runs).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "获取同步记录失败"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":      runs,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// GetSyncRun 获取单条同步记录，包含保存失败的订单明细
+// @Summary 获取Shein同步记录详情
+// @Tags Shein
+// @Produce json
+// @Param id path int true "同步记录ID"
+// @Router /api/shein/sync-runs/{id} [get]
+func (c *SheinController) GetSyncRun(ctx *gin.Context) {
+	var run models.SheinSyncRun
+	if err := database.DB.First(&run, ctx.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "同步记录不存在"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "获取同步记录失败"})
+		return
+	}
+
+	failures := []sheinSyncError{}
+	if run.Errors != "" {
+		if err := json.Unmarshal([]byte(run.Errors), &failures); err != nil {
+			log.Printf("failed to decode shein sync run %d errors: %v", run.ID, err)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"data":   run,
+		"errors": failures,
+	})
+}
+
+// GetSyncStates 获取各店铺的增量同步水位及待重试、已放弃重试的失败订单
+// @Summary 获取Shein同步水位
+// @Tags Shein
+// @Produce json
+// @Router /api/shein/sync-states [get]
+func (c *SheinController) GetSyncStates(ctx *gin.Context) {
+	var states []models.SheinSyncState
+	if err := database.DB.Order("shop_code").Find(&states).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "获取同步水位失败"})
+		return
+	}
+	var failures []models.SheinSyncFailure
+	if err := database.DB.Order("shop_code, id").Find(&failures).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "获取失败订单列表失败"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"data": states, "failures": failures})
+}
+
+// TriggerSyncRun 立即在后台执行一次增量同步，与定时任务共用水位和分布式锁
+// @Summary 触发Shein增量同步
+// @Tags Shein
+// @Produce json
+// @Success 202 {object} gin.H{"message": string}
+// @Failure 409 {object} gin.H{"error": string}
+// @Router /api/shein/sync-runs [post]
+func (c *SheinController) TriggerSyncRun(ctx *gin.Context) {
+	// 先确认没有正在进行的同步，实际加锁在后台任务中完成
+	running, err := utils.IsLocked(ctx.Request.Context(), sheinSyncLockName)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "检查同步状态失败"})
+		return
+	}
+	if running {
+		ctx.JSON(http.StatusConflict, gin.H{"error": errSheinSyncRunning.Error()})
+		return
+	}
+
+	userID := currentUserID(ctx)
+	go func() {
+		if _, err := runSheinSyncRecovered(models.SheinSyncTriggerManual, userID); err != nil {
+			log.Printf("manual shein incremental sync failed: %v", err)
+		}
+	}()
+
+	utils.LogAction(userID, ctx.GetString("username"), "触发Shein增量同步", "订单", "手动触发 Shein 订单增量同步",
+		utils.GetClientIP(ctx.Request), ctx.Request.UserAgent(), 1)
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "已开始同步，可在同步记录中查看结果"})
+}
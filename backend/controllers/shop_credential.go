@@ -0,0 +1,242 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/services"
+	"haodun_manage/backend/utils"
+)
+
+// shopCredentialRequest 新增或修改店铺凭证的请求体；修改时 app_secret 为空表示不修改密钥
+type shopCredentialRequest struct {
+	ShopCode    string `json:"shop_code"`
+	Name        string `json:"name"`
+	AppKey      string `json:"app_key"`
+	AppSecret   string `json:"app_secret"`
+	Environment string `json:"environment"`
+	BaseURL     string `json:"base_url"`
+	Enabled     *bool  `json:"enabled"`
+	Remark      string `json:"remark"`
+}
+
+// normalize 清理并校验请求内容
+func (r *shopCredentialRequest) normalize() error {
+	r.ShopCode = strings.TrimSpace(r.ShopCode)
+	r.Name = strings.TrimSpace(r.Name)
+	r.AppKey = strings.TrimSpace(r.AppKey)
+	r.AppSecret = strings.TrimSpace(r.AppSecret)
+	r.Environment = strings.ToLower(strings.TrimSpace(r.Environment))
+	r.BaseURL = strings.TrimRight(strings.TrimSpace(r.BaseURL), "/")
+	r.Remark = strings.TrimSpace(r.Remark)
+
+	if r.ShopCode == "" {
+		return errors.New("店铺编号不能为空")
+	}
+	if len([]rune(r.ShopCode)) > 32 {
+		return errors.New("店铺编号不能超过32个字符")
+	}
+	if r.AppKey == "" {
+		return errors.New("App Key 不能为空")
+	}
+	if r.Environment == "" {
+		r.Environment = models.ShopEnvTest
+	}
+	if r.Environment != models.ShopEnvProd && r.Environment != models.ShopEnvTest {
+		return fmt.Errorf("不支持的接口环境: %s", r.Environment)
+	}
+	if r.BaseURL != "" {
+		if err := services.ValidateSheinBaseURL(r.BaseURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryptSecretError 加密失败时返回给前端的提示
+func encryptSecretError(err error) string {
+	if errors.Is(err, utils.ErrCredentialKeyMissing) {
+		return err.Error()
+	}
+	return "加密密钥失败"
+}
+
+// shopCredentialResponse 返回给前端的店铺凭证，附带实际使用的接口地址
+func shopCredentialResponse(credential models.ShopCredential) gin.H {
+	baseURL := credential.BaseURL
+	if baseURL == "" {
+		baseURL = services.SheinBaseURLFor(credential.Environment)
+	}
+	return gin.H{
+		"id":                 credential.ID,
+		"shop_code":          credential.ShopCode,
+		"name":               credential.Name,
+		"app_key":            credential.AppKey,
+		"environment":        credential.Environment,
+		"base_url":           credential.BaseURL,
+		"effective_base_url": baseURL,
+		"enabled":            credential.Enabled,
+		"remark":             credential.Remark,
+		"created_by":         credential.CreatedBy,
+		"created_at":         credential.CreatedAt,
+		"updated_at":         credential.UpdatedAt,
+	}
+}
+
+// ListShopCredentials 获取店铺凭证列表，不返回密钥
+func ListShopCredentials(c *gin.Context) {
+	query := database.DB.Model(&models.ShopCredential{})
+	if keyword := strings.TrimSpace(c.Query("keyword")); keyword != "" {
+		query = query.Where("shop_code LIKE ? OR name LIKE ?", "%"+keyword+"%", "%"+keyword+"%")
+	}
+	if environment := c.Query("environment"); environment != "" {
+		query = query.Where("environment = ?", environment)
+	}
+	if enabled := c.Query("enabled"); enabled != "" {
+		query = query.Where("enabled = ?", enabled == "1" || enabled == "true")
+	}
+
+	var credentials []models.ShopCredential
+	if err := query.Order("shop_code").Find(&credentials).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取店铺凭证失败"})
+		return
+	}
+
+	list := make([]gin.H, 0, len(credentials))
+	for _, credential := range credentials {
+		list = append(list, shopCredentialResponse(credential))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": list})
+}
+
+// GetShopCredential 获取店铺凭证详情，不返回密钥
+func GetShopCredential(c *gin.Context) {
+	var credential models.ShopCredential
+	if err := database.DB.First(&credential, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "店铺凭证不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shopCredentialResponse(credential)})
+}
+
+// CreateShopCredential 新增店铺凭证，密钥加密后保存
+func CreateShopCredential(c *gin.Context) {
+	var req shopCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.AppSecret == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "App Secret 不能为空"})
+		return
+	}
+
+	var count int64
+	database.DB.Model(&models.ShopCredential{}).Where("shop_code = ?", req.ShopCode).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "该店铺已配置凭证"})
+		return
+	}
+
+	secret, err := utils.EncryptSecret(req.AppSecret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": encryptSecretError(err)})
+		return
+	}
+	credential := models.ShopCredential{
+		ShopCode:    req.ShopCode,
+		Name:        req.Name,
+		AppKey:      req.AppKey,
+		AppSecret:   secret,
+		Environment: req.Environment,
+		BaseURL:     req.BaseURL,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		Remark:      req.Remark,
+		CreatedBy:   currentUserID(c),
+	}
+	if err := database.DB.Create(&credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "创建店铺凭证失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shopCredentialResponse(credential)})
+}
+
+// UpdateShopCredential 修改店铺凭证，app_secret 为空时保留原密钥
+func UpdateShopCredential(c *gin.Context) {
+	var credential models.ShopCredential
+	if err := database.DB.First(&credential, c.Param("id")).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "店铺凭证不存在"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取店铺凭证失败"})
+		return
+	}
+
+	var req shopCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := req.normalize(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.ShopCode != credential.ShopCode {
+		var count int64
+		database.DB.Model(&models.ShopCredential{}).Where("shop_code = ? AND id != ?", req.ShopCode, credential.ID).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "该店铺已配置凭证"})
+			return
+		}
+	}
+
+	if req.AppSecret != "" {
+		secret, err := utils.EncryptSecret(req.AppSecret)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": encryptSecretError(err)})
+			return
+		}
+		credential.AppSecret = secret
+	}
+	credential.ShopCode = req.ShopCode
+	credential.Name = req.Name
+	credential.AppKey = req.AppKey
+	credential.Environment = req.Environment
+	credential.BaseURL = req.BaseURL
+	if req.Enabled != nil {
+		credential.Enabled = *req.Enabled
+	}
+	credential.Remark = req.Remark
+	if err := database.DB.Save(&credential).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "更新店铺凭证失败"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": shopCredentialResponse(credential)})
+}
+
+// DeleteShopCredential 删除店铺凭证
+func DeleteShopCredential(c *gin.Context) {
+	result := database.DB.Delete(&models.ShopCredential{}, c.Param("id"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "删除店铺凭证失败"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "店铺凭证不存在"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -69,6 +71,13 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	// Enforce password policy
+	policy := utils.LoadPasswordPolicy()
+	if err := policy.Validate(req.Password, req.Username); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Hash the password
 	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
@@ -104,20 +113,23 @@ func CreateUser(c *gin.Context) {
 		status = *req.Status
 	}
 
+	now := time.Now()
 	user := models.User{
-		Username:     req.Username,
-		Password:     hashedPassword,
-		Email:        req.Email,
-		Status:       status,
-		RoleID:       req.RoleID,
-		DepartmentID: req.DepartmentID,
-		EmployeeType: employeeType,
+		Username:          req.Username,
+		Password:          hashedPassword,
+		Email:             req.Email,
+		Status:            status,
+		RoleID:            req.RoleID,
+		DepartmentID:      req.DepartmentID,
+		EmployeeType:      employeeType,
+		PasswordChangedAt: &now,
 	}
 
 	if err := database.DB.Create(&user).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "创建用户失败"})
 		return
 	}
+	utils.RecordPasswordHistory(user.ID, hashedPassword, policy.HistoryCount)
 
 	database.DB.Preload("Role").Preload("Department").First(&user, user.ID)
 	user.Password = ""
@@ -148,6 +160,10 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	originalStatus := user.Status
+	originalRoleID := user.RoleID
+	passwordChanged := false
+
 	user.Username = req.Username
 	user.Email = req.Email
 	if req.Status != nil {
@@ -185,12 +201,21 @@ func UpdateUser(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "密码不能是哈希格式，请提供明文密码"})
 			return
 		}
+		if err := validateNewPassword(&user, req.Password); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		hashedPassword, err := utils.HashPassword(req.Password)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "密码加密失败"})
 			return
 		}
+		now := time.Now()
 		user.Password = hashedPassword
+		user.PasswordChangedAt = &now
+		// 管理员为他人重置的密码，用户下次登录后必须先修改
+		user.MustChangePassword = user.ID != currentUserID(c)
+		passwordChanged = true
 	}
 
 	if err := database.DB.Save(&user).Error; err != nil {
@@ -198,6 +223,15 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	if passwordChanged {
+		utils.RecordPasswordHistory(user.ID, user.Password, utils.LoadPasswordPolicy().HistoryCount)
+	}
+
+	// 状态、角色或密码变化后，已签发的令牌全部失效
+	if user.Status != originalStatus || user.RoleID != originalRoleID || passwordChanged {
+		utils.RevokeUserTokens(user.ID)
+	}
+
 	database.DB.Preload("Role").Preload("Department").First(&user, user.ID)
 	user.Password = ""
 	c.JSON(http.StatusOK, user)
@@ -206,9 +240,26 @@ func UpdateUser(c *gin.Context) {
 func DeleteUser(c *gin.Context) {
 	id := c.Param("id")
 	database.DB.Delete(&models.User{}, id)
+	if userID, err := strconv.ParseUint(id, 10, 64); err == nil {
+		utils.RevokeUserTokens(uint(userID))
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
+// validateNewPassword 按密码策略校验新密码，并禁止与当前及最近使用过的密码相同
+func validateNewPassword(user *models.User, password string) error {
+	policy := utils.LoadPasswordPolicy()
+	if err := policy.Validate(password, user.Username); err != nil {
+		return err
+	}
+	if policy.HistoryCount > 0 {
+		if utils.CheckPasswordHash(password, user.Password) || policy.IsPasswordReused(user.ID, password) {
+			return fmt.Errorf("新密码不能与最近%d次使用过的密码相同", policy.HistoryCount)
+		}
+	}
+	return nil
+}
+
 func isValidEmployeeType(value string) bool {
 	switch strings.ToLower(value) {
 	case "internal", "external":
@@ -2,7 +2,9 @@ package database
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"haodun_manage/backend/config"
 	"haodun_manage/backend/models"
@@ -31,6 +33,11 @@ func InitDB() {
 		panic(fmt.Sprintf("Failed to connect database: %v", err))
 	}
 
+	// 资源唯一键由 path 调整为 path + method
+	if DB.Migrator().HasIndex(&models.Resource{}, "idx_resources_path") {
+		DB.Migrator().DropIndex(&models.Resource{}, "idx_resources_path")
+	}
+
 	// 自动迁移
 	DB.AutoMigrate(
 		&models.Department{},
@@ -47,10 +54,22 @@ func InitDB() {
 		&models.NoticeRead{},
 		&models.OrderInfo{},
 		&models.OrderAttachment{},
+		&models.OrderStatusHistory{},
+		&models.ImportJob{},
+		&models.ImportProfile{},
+		&models.SheinSyncState{},
+		&models.SheinSyncRun{},
+		&models.SheinSyncFailure{},
+		&models.ShopCredential{},
 		&models.MaterialFolder{},
 		&models.MaterialAsset{},
+		&models.RefreshToken{},
+		&models.PasswordHistory{},
 	)
 
+	// 密码有效期从首次上线时开始计算
+	DB.Model(&models.User{}).Where("password_changed_at IS NULL").Update("password_changed_at", time.Now())
+
 	// 初始化默认数据
 	initDefaultData()
 	// 从系统参数加载动态配置
@@ -71,9 +90,16 @@ func initDefaultData() {
 	// 创建默认管理员角色
 	adminRole := models.Role{}
 	DB.Where(models.Role{Name: "admin"}).
-		Attrs(models.Role{Description: "系统管理员"}).
+		Attrs(models.Role{Description: "系统管理员", DataScope: models.DataScopeAll, IsSuperAdmin: true}).
 		FirstOrCreate(&adminRole)
 
+	// 旧版本以角色ID=1判断管理员，升级后若尚无超级管理员角色则授予默认管理员角色
+	var superAdminCount int64
+	DB.Model(&models.Role{}).Where("is_super_admin = ?", true).Count(&superAdminCount)
+	if superAdminCount == 0 {
+		DB.Model(&adminRole).Update("is_super_admin", true)
+	}
+
 	// 创建默认管理员用户
 	adminUser := models.User{}
 	DB.Where(models.User{Username: "admin"}).
@@ -127,6 +153,35 @@ func initDefaultData() {
 		}).
 		FirstOrCreate(&models.Config{})
 
+	// 安全配置：验证码、密码策略
+	securityConfigs := []models.Config{
+		{Key: "captcha_length", Value: "4", Label: "验证码长度", Type: "number", Description: "验证码字符个数（1-8）", Sort: 1},
+		{Key: "captcha_charset", Value: "23456789ABCDEFGHJKMNPQRSTUVWXYZ", Label: "验证码字符集", Type: "text", Description: "验证码可选字符（数字与大写字母）", Sort: 2},
+		{Key: "captcha_ttl_seconds", Value: "300", Label: "验证码有效期（秒）", Type: "number", Description: "验证码过期时间", Sort: 3},
+		{Key: "captcha_rate_limit", Value: "20", Label: "验证码获取频率限制", Type: "number", Description: "每个IP每分钟最多获取的验证码数量，0表示不限制", Sort: 4},
+		{Key: "password_min_length", Value: "8", Label: "密码最小长度", Type: "number", Description: "密码最少字符数", Sort: 10},
+		{Key: "password_min_char_classes", Value: "3", Label: "密码字符类别数", Type: "number", Description: "小写字母、大写字母、数字、特殊字符中至少包含的类别数（0-4）", Sort: 11},
+		{Key: "password_disallow_username", Value: "1", Label: "禁止密码包含用户名", Type: "boolean", Description: "1-禁止，0-允许", Sort: 12},
+		{Key: "password_history_count", Value: "5", Label: "禁止重复使用的历史密码数", Type: "number", Description: "新密码不能与最近N次密码相同，0表示不限制", Sort: 13},
+		{Key: "password_max_age_days", Value: "90", Label: "密码最长使用天数", Type: "number", Description: "超过天数后登录时要求修改密码，0表示不限制", Sort: 14},
+	}
+	for _, item := range securityConfigs {
+		DB.Where(models.Config{Key: item.Key}).
+			Attrs(models.Config{
+				Value:       item.Value,
+				Label:       item.Label,
+				Type:        item.Type,
+				Group:       "security",
+				Description: item.Description,
+				Sort:        item.Sort,
+				Status:      1,
+			}).
+			FirstOrCreate(&models.Config{})
+	}
+
+	initDefaultAPIResources(adminRole.ID)
+	initOrderStatusDict()
+
 	defaultMaterialFolder := models.MaterialFolder{}
 	DB.Where(models.MaterialFolder{Name: "默认文件夹"}).
 		Attrs(models.MaterialFolder{Path: "默认文件夹"}).
@@ -169,6 +224,119 @@ func initDefaultData() {
 		FirstOrCreate(&models.Config{})
 }
 
+// initDefaultAPIResources 登记需要鉴权的管理接口，仅默认授予管理员角色
+func initDefaultAPIResources(adminRoleID uint) {
+	type apiResource struct {
+		Name   string
+		Path   string
+		Method string
+		Code   string
+	}
+
+	items := []apiResource{
+		{"新增用户", "/api/users", "POST", "api:users:create"},
+		{"编辑用户", "/api/users/:id", "PUT", "api:users:update"},
+		{"删除用户", "/api/users/:id", "DELETE", "api:users:delete"},
+		{"解除登录锁定", "/api/users/:id/unlock", "POST", "api:users:unlock"},
+		{"重置二次验证", "/api/users/:id/mfa/reset", "POST", "api:users:mfa-reset"},
+		{"查看全部会话", "/api/sessions", "GET", "api:sessions:list"},
+		{"终止用户会话", "/api/sessions/:id", "DELETE", "api:sessions:delete"},
+		{"新增角色", "/api/roles", "POST", "api:roles:create"},
+		{"编辑角色", "/api/roles/:id", "PUT", "api:roles:update"},
+		{"删除角色", "/api/roles/:id", "DELETE", "api:roles:delete"},
+		{"分配角色权限", "/api/roles/:id/permissions", "POST", "api:roles:assign"},
+		{"新增权限", "/api/permissions", "POST", "api:permissions:create"},
+		{"编辑权限", "/api/permissions/:id", "PUT", "api:permissions:update"},
+		{"删除权限", "/api/permissions/:id", "DELETE", "api:permissions:delete"},
+		{"新增资源", "/api/resources", "POST", "api:resources:create"},
+		{"编辑资源", "/api/resources/:id", "PUT", "api:resources:update"},
+		{"删除资源", "/api/resources/:id", "DELETE", "api:resources:delete"},
+		{"新增系统参数", "/api/configs", "POST", "api:configs:create"},
+		{"编辑系统参数", "/api/configs/:id", "PUT", "api:configs:update"},
+		{"删除系统参数", "/api/configs/:id", "DELETE", "api:configs:delete"},
+		{"修改存储设置", "/api/storage/settings", "PUT", "api:storage:update"},
+		{"批量操作订单", "/api/orders/bulk", "POST", "api:orders:bulk"},
+		{"流转订单状态", "/api/orders/:id/transition", "POST", "api:orders:transition"},
+		{"查询Shein订单列表", "/api/shein/order-list", "GET", "api:shein:order-list"},
+		{"查询Shein订单详情", "/api/shein/order-detail", "GET", "api:shein:order-detail"},
+		{"同步Shein订单", "/api/shein/sync-orders", "POST", "api:shein:sync"},
+		{"查看Shein同步记录", "/api/shein/sync-runs", "GET", "api:shein:sync-runs"},
+		{"查看Shein同步记录详情", "/api/shein/sync-runs/:id", "GET", "api:shein:sync-run-detail"},
+		{"查看Shein同步状态", "/api/shein/sync-states", "GET", "api:shein:sync-states"},
+		{"触发Shein增量同步", "/api/shein/sync-runs", "POST", "api:shein:sync-run"},
+		{"查看店铺凭证", "/api/shop-credentials", "GET", "api:shop-credentials:list"},
+		{"查看店铺凭证详情", "/api/shop-credentials/:id", "GET", "api:shop-credentials:detail"},
+		{"新增店铺凭证", "/api/shop-credentials", "POST", "api:shop-credentials:create"},
+		{"编辑店铺凭证", "/api/shop-credentials/:id", "PUT", "api:shop-credentials:update"},
+		{"删除店铺凭证", "/api/shop-credentials/:id", "DELETE", "api:shop-credentials:delete"},
+		{"新增导入模板", "/api/import-profiles", "POST", "api:import-profiles:create"},
+		{"编辑导入模板", "/api/import-profiles/:id", "PUT", "api:import-profiles:update"},
+		{"删除导入模板", "/api/import-profiles/:id", "DELETE", "api:import-profiles:delete"},
+		{"新增部门", "/api/departments", "POST", "api:departments:create"},
+		{"编辑部门", "/api/departments/:id", "PUT", "api:departments:update"},
+		{"删除部门", "/api/departments/:id", "DELETE", "api:departments:delete"},
+		{"新增字典类型", "/api/dict-types", "POST", "api:dict-types:create"},
+		{"编辑字典类型", "/api/dict-types/:id", "PUT", "api:dict-types:update"},
+		{"删除字典类型", "/api/dict-types/:id", "DELETE", "api:dict-types:delete"},
+		{"新增字典项", "/api/dict-items", "POST", "api:dict-items:create"},
+		{"编辑字典项", "/api/dict-items/:id", "PUT", "api:dict-items:update"},
+		{"删除字典项", "/api/dict-items/:id", "DELETE", "api:dict-items:delete"},
+		{"发布公告", "/api/notices", "POST", "api:notices:create"},
+		{"编辑公告", "/api/notices/:id", "PUT", "api:notices:update"},
+		{"删除公告", "/api/notices/:id", "DELETE", "api:notices:delete"},
+	}
+
+	for i, item := range items {
+		resource := models.Resource{}
+		DB.Where(models.Resource{Path: item.Path, Method: item.Method}).
+			Attrs(models.Resource{
+				Name:        item.Name,
+				Description: item.Name + "接口",
+				Type:        "api",
+				Sort:        i,
+			}).
+			FirstOrCreate(&resource)
+		if resource.ID == 0 {
+			continue
+		}
+
+		permission := models.Permission{}
+		DB.Where(models.Permission{Code: item.Code}).
+			Attrs(models.Permission{
+				Name:        item.Name + "接口权限",
+				Description: "调用" + item.Name + "接口",
+				ResourceID:  resource.ID,
+			}).
+			FirstOrCreate(&permission)
+		if permission.ID == 0 || adminRoleID == 0 {
+			continue
+		}
+
+		DB.Exec("INSERT IGNORE INTO role_permissions (role_id, permission_id) VALUES (?, ?)", adminRoleID, permission.ID)
+	}
+}
+
+// initOrderStatusDict 补齐订单状态字典，已存在的字典项保持不变
+func initOrderStatusDict() {
+	dictType := models.DictType{}
+	DB.Where(models.DictType{Code: "order_status"}).
+		Attrs(models.DictType{Name: "订单状态", Status: 1}).
+		FirstOrCreate(&dictType)
+	if dictType.ID == 0 {
+		return
+	}
+
+	for i, status := range models.OrderStatuses {
+		DB.Where(models.DictItem{TypeID: dictType.ID, Value: strconv.Itoa(int(status))}).
+			Attrs(models.DictItem{
+				Label:  models.OrderStatusName(status),
+				Sort:   i,
+				Status: 1,
+			}).
+			FirstOrCreate(&models.DictItem{})
+	}
+}
+
 func loadStorageSettingsFromDB() {
 	if config.AppConfig == nil {
 		return
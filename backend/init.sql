@@ -79,7 +79,7 @@ CREATE TABLE IF NOT EXISTS `resources` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
   `name` varchar(255) NOT NULL COMMENT '资源名称',
   `path` varchar(255) NOT NULL COMMENT '资源路径',
-  `method` varchar(255) NOT NULL COMMENT '请求方法: GET, POST, PUT, DELETE',
+  `method` varchar(16) NOT NULL COMMENT '请求方法: GET, POST, PUT, DELETE',
   `description` varchar(255) DEFAULT NULL COMMENT '描述',
   `type` varchar(255) DEFAULT NULL COMMENT '类型: api, menu, button',
   `parent_id` bigint unsigned DEFAULT NULL COMMENT '父资源ID',
@@ -89,7 +89,7 @@ CREATE TABLE IF NOT EXISTS `resources` (
   `updated_at` datetime(3) DEFAULT NULL,
   `deleted_at` datetime(3) DEFAULT NULL,
   PRIMARY KEY (`id`),
-  UNIQUE KEY `idx_resources_path` (`path`),
+  UNIQUE KEY `idx_resources_path_method` (`path`, `method`),
   KEY `idx_resources_deleted_at` (`deleted_at`),
   KEY `idx_resources_parent_id` (`parent_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='资源表';
@@ -282,7 +282,7 @@ CREATE TABLE IF NOT EXISTS `dict_items` (
   KEY `idx_dict_items_deleted_at` (`deleted_at`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='字典项表';
 
-INSERT INTO `dict_items` VALUES (1,1,'YC.BY','YC.BY',0,1,'','2025-11-09 21:53:18.455','2025-11-09 21:53:18.455',NULL),(2,1,'YC.D','YC.D',1,1,'','2025-11-09 21:53:28.478','2025-11-09 21:53:52.865',NULL),(3,1,'YC.X','YC.X',2,1,'','2025-11-09 21:53:39.600','2025-11-09 21:54:04.471',NULL),(4,1,'YZG.CA','YZG.CA',3,1,'','2025-11-09 21:54:21.375','2025-11-09 21:54:21.375',NULL),(5,1,'YZG.D','YZG.D',4,1,'','2025-11-09 21:54:32.542','2025-11-09 21:54:32.542',NULL),(6,1,'YZG.X','YZG.X',5,1,'','2025-11-09 21:55:04.730','2025-11-09 21:55:04.730',NULL),(7,1,'QQH.NY','QQH.NY',6,1,'','2025-11-09 21:55:13.965','2025-11-09 21:55:13.965',NULL),(8,1,'QQH.WV','QQH.WV',7,1,'','2025-11-09 21:55:22.511','2025-11-09 21:55:22.511',NULL),(9,1,'TX.X','TX.X',8,1,'','2025-11-09 21:55:31.064','2025-11-09 21:55:35.837',NULL),(10,1,'TX.D','TX.D',9,1,'','2025-11-09 21:55:44.036','2025-11-09 21:55:44.036',NULL),(11,1,'HTC.X','HTC.X',9,1,'','2025-11-09 21:55:51.394','2025-11-09 21:56:03.938',NULL),(12,1,'HTX.D','HTX.D',10,1,'','2025-11-09 21:56:16.050','2025-11-09 21:56:16.050',NULL),(13,2,'完成','1',0,1,'','2025-11-11 10:47:22.427','2025-11-11 10:47:22.427',NULL),(14,2,'未完成','0',1,1,'','2025-11-11 10:47:31.902','2025-11-11 10:47:31.902',NULL);
+INSERT INTO `dict_items` VALUES (1,1,'YC.BY','YC.BY',0,1,'','2025-11-09 21:53:18.455','2025-11-09 21:53:18.455',NULL),(2,1,'YC.D','YC.D',1,1,'','2025-11-09 21:53:28.478','2025-11-09 21:53:52.865',NULL),(3,1,'YC.X','YC.X',2,1,'','2025-11-09 21:53:39.600','2025-11-09 21:54:04.471',NULL),(4,1,'YZG.CA','YZG.CA',3,1,'','2025-11-09 21:54:21.375','2025-11-09 21:54:21.375',NULL),(5,1,'YZG.D','YZG.D',4,1,'','2025-11-09 21:54:32.542','2025-11-09 21:54:32.542',NULL),(6,1,'YZG.X','YZG.X',5,1,'','2025-11-09 21:55:04.730','2025-11-09 21:55:04.730',NULL),(7,1,'QQH.NY','QQH.NY',6,1,'','2025-11-09 21:55:13.965','2025-11-09 21:55:13.965',NULL),(8,1,'QQH.WV','QQH.WV',7,1,'','2025-11-09 21:55:22.511','2025-11-09 21:55:22.511',NULL),(9,1,'TX.X','TX.X',8,1,'','2025-11-09 21:55:31.064','2025-11-09 21:55:35.837',NULL),(10,1,'TX.D','TX.D',9,1,'','2025-11-09 21:55:44.036','2025-11-09 21:55:44.036',NULL),(11,1,'HTC.X','HTC.X',9,1,'','2025-11-09 21:55:51.394','2025-11-09 21:56:03.938',NULL),(12,1,'HTX.D','HTX.D',10,1,'','2025-11-09 21:56:16.050','2025-11-09 21:56:16.050',NULL),(13,2,'已完成','1',5,1,'','2025-11-11 10:47:22.427','2025-11-11 10:47:22.427',NULL),(14,2,'待处理','0',0,1,'','2025-11-11 10:47:31.902','2025-11-11 10:47:31.902',NULL),(15,2,'素材就绪','2',1,1,'','2025-11-11 10:47:31.902','2025-11-11 10:47:31.902',NULL),(16,2,'面单就绪','3',2,1,'','2025-11-11 10:47:31.902','2025-11-11 10:47:31.902',NULL),(17,2,'生产中','4',3,1,'','2025-11-11 10:47:31.902','2025-11-11 10:47:31.902',NULL),(18,2,'已发货','5',4,1,'','2025-11-11 10:47:31.902','2025-11-11 10:47:31.902',NULL),(19,2,'已取消','6',6,1,'','2025-11-11 10:47:31.902','2025-11-11 10:47:31.902',NULL);
 
 
 
@@ -503,6 +503,150 @@ CREATE TABLE IF NOT EXISTS `order_attachments` (
   KEY `idx_order_attachments_material_id` (`material_id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='订单附件表';
 
+DROP TABLE IF EXISTS `order_status_history`;
+-- 订单状态流转记录表
+CREATE TABLE IF NOT EXISTS `order_status_history` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `order_id` bigint unsigned NOT NULL COMMENT '订单ID',
+  `from_status` tinyint NOT NULL COMMENT '原状态',
+  `to_status` tinyint NOT NULL COMMENT '新状态',
+  `operator_id` bigint unsigned DEFAULT NULL COMMENT '操作人ID',
+  `operator_name` varchar(64) DEFAULT NULL COMMENT '操作人',
+  `remark` varchar(255) DEFAULT NULL COMMENT '备注',
+  `created_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_order_status_history_order_id` (`order_id`),
+  KEY `idx_order_status_history_operator_id` (`operator_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='订单状态流转记录表';
+
+DROP TABLE IF EXISTS `import_jobs`;
+-- 后台导入任务表
+CREATE TABLE IF NOT EXISTS `import_jobs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `type` varchar(32) NOT NULL COMMENT '导入类型',
+  `status` varchar(16) NOT NULL COMMENT '状态: pending/running/succeeded/partial/failed',
+  `file_name` varchar(255) DEFAULT NULL COMMENT '上传文件名',
+  `format` varchar(16) DEFAULT NULL COMMENT '文件格式: xlsx/csv/jsonl',
+  `dry_run` tinyint(1) DEFAULT NULL COMMENT '是否仅预检',
+  `on_error` varchar(16) DEFAULT 'abort' COMMENT '校验错误处理方式: abort/skip',
+  `profile_id` bigint unsigned DEFAULT NULL COMMENT '导入模板ID',
+  `total_rows` bigint DEFAULT NULL COMMENT '数据行数',
+  `processed_rows` bigint DEFAULT NULL COMMENT '已处理行数',
+  `success_rows` bigint DEFAULT NULL COMMENT '成功行数',
+  `failed_rows` bigint DEFAULT NULL COMMENT '失败行数',
+  `error_count` bigint DEFAULT NULL COMMENT '错误数',
+  `message` varchar(512) DEFAULT NULL COMMENT '结果说明',
+  `errors` longtext COMMENT '校验错误明细(JSON)',
+  `error_report_path` varchar(512) DEFAULT NULL COMMENT '错误报告存储路径',
+  `result` longtext COMMENT '预检结果(JSON)',
+  `created_by` bigint unsigned DEFAULT NULL COMMENT '创建人ID',
+  `owner` varchar(64) DEFAULT NULL COMMENT '执行任务的服务实例',
+  `heartbeat_at` datetime(3) DEFAULT NULL COMMENT '最近一次心跳时间',
+  `started_at` datetime(3) DEFAULT NULL,
+  `finished_at` datetime(3) DEFAULT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_import_jobs_type` (`type`),
+  KEY `idx_import_jobs_status` (`status`),
+  KEY `idx_import_jobs_created_by` (`created_by`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='后台导入任务表';
+
+DROP TABLE IF EXISTS `import_profiles`;
+-- 订单导入模板表（表头别名与订单字段的映射）
+CREATE TABLE IF NOT EXISTS `import_profiles` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(64) NOT NULL COMMENT '模板名称',
+  `shop_code` varchar(32) DEFAULT NULL COMMENT '适用店铺，为空表示通用',
+  `description` varchar(255) DEFAULT NULL COMMENT '描述',
+  `status` bigint DEFAULT '1' COMMENT '状态：1-启用，0-禁用',
+  `sheets` longtext COMMENT '工作表映射规则(JSON)',
+  `created_by` bigint unsigned DEFAULT NULL COMMENT '创建人ID',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  `deleted_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_import_profiles_name` (`name`),
+  KEY `idx_import_profiles_shop_code` (`shop_code`),
+  KEY `idx_import_profiles_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='订单导入模板表';
+
+DROP TABLE IF EXISTS `shein_sync_states`;
+-- Shein 订单增量同步水位表（每个店铺一条）
+CREATE TABLE IF NOT EXISTS `shein_sync_states` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `shop_code` varchar(32) NOT NULL COMMENT '店铺编号',
+  `watermark` datetime(3) DEFAULT NULL COMMENT '已完整同步到的时间',
+  `last_run_id` bigint unsigned DEFAULT NULL COMMENT '最近一次同步记录ID',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_shein_sync_states_shop_code` (`shop_code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Shein订单同步水位表';
+
+DROP TABLE IF EXISTS `shein_sync_runs`;
+-- Shein 订单同步记录表
+CREATE TABLE IF NOT EXISTS `shein_sync_runs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `shop_code` varchar(32) DEFAULT NULL COMMENT '店铺编号',
+  `trigger_type` varchar(16) NOT NULL COMMENT '触发方式：schedule-定时，manual-手动',
+  `status` varchar(16) NOT NULL COMMENT '状态：running/succeeded/partial/failed',
+  `window_start` datetime(3) DEFAULT NULL COMMENT '拉取开始时间',
+  `window_end` datetime(3) DEFAULT NULL COMMENT '拉取结束时间',
+  `total` bigint DEFAULT 0 COMMENT '拉取订单数',
+  `created` bigint DEFAULT 0 COMMENT '新增数',
+  `updated` bigint DEFAULT 0 COMMENT '更新数',
+  `skipped` bigint DEFAULT 0 COMMENT '跳过数',
+  `failed` bigint DEFAULT 0 COMMENT '失败数',
+  `message` varchar(512) DEFAULT NULL COMMENT '结果说明',
+  `errors` longtext COMMENT '失败订单明细(JSON)',
+  `created_by` bigint unsigned DEFAULT NULL COMMENT '触发人ID，定时任务为0',
+  `started_at` datetime(3) DEFAULT NULL,
+  `finished_at` datetime(3) DEFAULT NULL,
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_shein_sync_runs_shop_code` (`shop_code`),
+  KEY `idx_shein_sync_runs_status` (`status`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Shein订单同步记录表';
+
+DROP TABLE IF EXISTS `shein_sync_failures`;
+-- Shein 增量同步失败订单表（按订单号单独重试）
+CREATE TABLE IF NOT EXISTS `shein_sync_failures` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `shop_code` varchar(32) NOT NULL COMMENT '店铺编号',
+  `order_no` varchar(64) NOT NULL COMMENT 'Shein 订单号',
+  `attempts` bigint DEFAULT 0 COMMENT '已失败次数',
+  `dead` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否已超过重试上限',
+  `message` varchar(512) DEFAULT NULL COMMENT '最近一次失败原因',
+  `last_run_id` bigint unsigned DEFAULT NULL COMMENT '最近一次失败的同步记录ID',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_shein_sync_failures_order` (`shop_code`,`order_no`),
+  KEY `idx_shein_sync_failures_dead` (`dead`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Shein同步失败订单表';
+
+DROP TABLE IF EXISTS `shop_credentials`;
+-- 店铺 Shein 开放平台凭证表
+CREATE TABLE IF NOT EXISTS `shop_credentials` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `shop_code` varchar(32) NOT NULL COMMENT '店铺编号，与订单的 shop_code 对应',
+  `name` varchar(64) DEFAULT NULL COMMENT '店铺名称',
+  `app_key` varchar(128) NOT NULL COMMENT 'App Key',
+  `app_secret` varchar(512) NOT NULL COMMENT 'App Secret（加密存储）',
+  `environment` varchar(16) NOT NULL DEFAULT 'test' COMMENT '接口环境：prod-正式，test-测试',
+  `base_url` varchar(255) DEFAULT NULL COMMENT '接口地址，为空时按环境使用默认地址',
+  `enabled` tinyint(1) DEFAULT NULL COMMENT '是否启用',
+  `remark` varchar(255) DEFAULT NULL COMMENT '备注',
+  `created_by` bigint unsigned DEFAULT NULL COMMENT '创建人ID',
+  `created_at` datetime(3) DEFAULT NULL,
+  `updated_at` datetime(3) DEFAULT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_shop_credentials_shop_code` (`shop_code`),
+  KEY `idx_shop_credentials_enabled` (`enabled`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='店铺凭证表';
+
 DROP TABLE IF EXISTS `material_folders`;
 CREATE TABLE IF NOT EXISTS `material_folders` (
   `id` bigint unsigned NOT NULL AUTO_INCREMENT,
@@ -7,8 +7,10 @@ import (
 	"strings"
 
 	"haodun_manage/backend/config"
+	"haodun_manage/backend/controllers"
 	"haodun_manage/backend/database"
 	"haodun_manage/backend/router"
+	"haodun_manage/backend/services"
 	"haodun_manage/backend/utils"
 
 	"github.com/gin-gonic/gin"
@@ -60,12 +62,28 @@ func main() {
 	// 初始化配置
 	config.InitConfig()
 
+	if !utils.CredentialKeyConfigured() {
+		fmt.Println("警告: 未配置 CREDENTIAL_ENCRYPT_KEY（至少16个字符，且不能与 JWT_SECRET 相同），店铺凭证无法保存或使用，也无法开启二次验证")
+	}
+
 	// 初始化数据库
 	database.InitDB()
 
 	// 初始化Redis
 	database.InitRedis()
 
+	// 导入旧版本环境变量中的 Shein 店铺凭证
+	services.ImportEnvSheinCredential()
+
+	// 加密旧版本明文保存的二次验证密钥
+	controllers.EncryptLegacyTOTPSecrets()
+
+	// 回收已中断的导入任务
+	controllers.StartImportJobMonitor()
+
+	// 启动 Shein 订单定时同步
+	controllers.StartSheinSyncScheduler()
+
 	// 初始化路由
 	r := gin.Default()
 
@@ -75,6 +93,9 @@ func main() {
 	// 注册路由
 	router.SetupRoutes(r)
 
+	// 检查未登记为资源且不在开放列表中的接口
+	utils.CheckRouteResources(r.Routes())
+
 	// 启动服务器
 	port := os.Getenv("PORT")
 	if port == "" {
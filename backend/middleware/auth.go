@@ -36,12 +36,49 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		if utils.IsTokenRevoked(claims) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "认证令牌已失效，请重新登录"})
+			c.Abort()
+			return
+		}
+
+		// 必须修改密码时，只允许修改密码和注销
+		if claims.MustChangePassword && !passwordChangeAllowed(c.Request.Method, c.FullPath()) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":                "请先修改密码",
+				"reason":               "password_change_required",
+				"must_change_password": true,
+			})
+			c.Abort()
+			return
+		}
+
+		superAdmin, err := utils.IsSuperAdminRole(claims.RoleID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "权限校验失败", "reason": "permission_lookup_failed"})
+			c.Abort()
+			return
+		}
+
+		utils.TouchSession(claims.FamilyID, utils.GetClientIP(c.Request))
+
 		// 将用户信息存储到上下文
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("role_id", claims.RoleID)
+		c.Set("is_super_admin", superAdmin)
+		c.Set("token_claims", claims)
 
 		c.Next()
 	}
 }
 
+// passwordChangeAllowed 必须修改密码的令牌可以调用的接口
+func passwordChangeAllowed(method, fullPath string) bool {
+	switch utils.ResourceKey(method, fullPath) {
+	case utils.ResourceKey(http.MethodPut, "/api/auth/change-password"),
+		utils.ResourceKey(http.MethodPost, "/api/auth/logout"):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// 导入任务状态
+const (
+	ImportJobPending   = "pending"   // 等待执行
+	ImportJobRunning   = "running"   // 执行中
+	ImportJobSucceeded = "succeeded" // 执行成功
+	ImportJobPartial   = "partial"   // 部分成功，出错的行已跳过
+	ImportJobFailed    = "failed"    // 执行失败
+)
+
+// 导入遇到校验错误时的处理方式
+const (
+	ImportOnErrorAbort = "abort" // 任一行出错则整个文件不导入
+	ImportOnErrorSkip  = "skip"  // 跳过出错的行，导入其余有效行
+)
+
+// ImportJob 后台导入任务
+type ImportJob struct {
+	ID              uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	Type            string     `json:"type" gorm:"size:32;not null;index"` // 导入类型，如 order
+	Status          string     `json:"status" gorm:"size:16;not null;index"`
+	FileName        string     `json:"file_name" gorm:"size:255"`
+	Format          string     `json:"format" gorm:"size:16"`                   // 文件格式：xlsx / csv / jsonl
+	DryRun          bool       `json:"dry_run"`                                 // 仅预检，不写入数据
+	OnError         string     `json:"on_error" gorm:"size:16;default:'abort'"` // 校验错误处理方式
+	ProfileID       *uint64    `json:"profile_id"`                              // 导入模板ID，为空表示内置规则
+	TotalRows       int        `json:"total_rows"`
+	ProcessedRows   int        `json:"processed_rows"`
+	SuccessRows     int        `json:"success_rows"`
+	FailedRows      int        `json:"failed_rows"`
+	ErrorCount      int        `json:"error_count"`
+	Message         string     `json:"message" gorm:"size:512"`
+	Errors          string     `json:"-" gorm:"type:longtext"` // 校验错误明细（JSON）
+	ErrorReportPath string     `json:"-" gorm:"size:512"`      // 标注错误的工作簿在本地存储中的路径
+	Result          string     `json:"-" gorm:"type:longtext"` // 预检结果（JSON）
+	CreatedBy       uint       `json:"created_by" gorm:"index"`
+	Owner           string     `json:"owner" gorm:"size:64"` // 执行任务的服务实例
+	HeartbeatAt     *time.Time `json:"heartbeat_at"`         // 执行实例最近一次心跳，超时未更新视为任务已中断
+	StartedAt       *time.Time `json:"started_at"`
+	FinishedAt      *time.Time `json:"finished_at"`
+}
+
+// IsFinished 任务是否已结束
+func (j *ImportJob) IsFinished() bool {
+	return j.Status == ImportJobSucceeded || j.Status == ImportJobPartial || j.Status == ImportJobFailed
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ImportColumnMapping 导入列映射：表头别名对应的订单字段
+type ImportColumnMapping struct {
+	Field    string   `json:"field"`    // 订单字段，取 OrderInfo 的 JSON 名，如 gsp_order_no
+	Aliases  []string `json:"aliases"`  // 表头别名，匹配时忽略大小写、空格及常见标点
+	Required bool     `json:"required"` // 必填：缺少该列或单元格为空时报错
+	Default  string   `json:"default"`  // 默认值：缺少该列或单元格为空时使用
+}
+
+// ImportSheetMapping 单个工作表的映射规则
+type ImportSheetMapping struct {
+	OrderType     string                `json:"order_type"`     // platform 平台面单 / factory 工厂物流
+	SheetKeywords []string              `json:"sheet_keywords"` // 工作表名称关键词，为空时使用内置关键词
+	Columns       []ImportColumnMapping `json:"columns"`
+}
+
+// ImportProfile 订单导入模板，用于适配不同供应商的表头；
+// 未配置的字段仍按内置关键词识别
+type ImportProfile struct {
+	ID          uint64               `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt       `json:"-" gorm:"index"`
+	Name        string               `json:"name" gorm:"size:64;not null;uniqueIndex"`
+	ShopCode    string               `json:"shop_code" gorm:"size:32;index"` // 适用店铺，为空表示通用
+	Description string               `json:"description" gorm:"size:255"`
+	Status      int                  `json:"status" gorm:"default:1"` // 状态：1-启用，0-禁用
+	Sheets      []ImportSheetMapping `json:"sheets" gorm:"type:longtext;serializer:json"`
+	CreatedBy   uint                 `json:"created_by"`
+	BuiltIn     bool                 `json:"built_in" gorm:"-"` // 内置默认模板，不可修改
+}
+
+// SheetMapping 返回指定订单类型的工作表映射，未配置时返回 nil
+func (p *ImportProfile) SheetMapping(orderType string) *ImportSheetMapping {
+	if p == nil {
+		return nil
+	}
+	for i := range p.Sheets {
+		if p.Sheets[i].OrderType == orderType {
+			return &p.Sheets[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import "time"
+
+// 订单状态，0/1 沿用原有的"未完成/完成"取值
+const (
+	OrderStatusPending       int8 = 0 // 待处理
+	OrderStatusCompleted     int8 = 1 // 已完成
+	OrderStatusMaterialReady int8 = 2 // 素材就绪
+	OrderStatusLabelReady    int8 = 3 // 面单就绪
+	OrderStatusInProduction  int8 = 4 // 生产中
+	OrderStatusShipped       int8 = 5 // 已发货
+	OrderStatusCancelled     int8 = 6 // 已取消
+)
+
+// OrderStatuses 按生命周期顺序排列的全部订单状态
+var OrderStatuses = []int8{
+	OrderStatusPending,
+	OrderStatusMaterialReady,
+	OrderStatusLabelReady,
+	OrderStatusInProduction,
+	OrderStatusShipped,
+	OrderStatusCompleted,
+	OrderStatusCancelled,
+}
+
+var orderStatusNames = map[int8]string{
+	OrderStatusPending:       "待处理",
+	OrderStatusCompleted:     "已完成",
+	OrderStatusMaterialReady: "素材就绪",
+	OrderStatusLabelReady:    "面单就绪",
+	OrderStatusInProduction:  "生产中",
+	OrderStatusShipped:       "已发货",
+	OrderStatusCancelled:     "已取消",
+}
+
+// orderStatusTransitions 各状态允许流转到的下一状态，已完成与已取消为终态
+var orderStatusTransitions = map[int8][]int8{
+	OrderStatusPending:       {OrderStatusMaterialReady, OrderStatusCancelled},
+	OrderStatusMaterialReady: {OrderStatusLabelReady, OrderStatusPending, OrderStatusCancelled},
+	OrderStatusLabelReady:    {OrderStatusInProduction, OrderStatusMaterialReady, OrderStatusCancelled},
+	OrderStatusInProduction:  {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:       {OrderStatusCompleted},
+	OrderStatusCompleted:     {},
+	OrderStatusCancelled:     {},
+}
+
+// OrderStatusName 返回订单状态的显示名称
+func OrderStatusName(status int8) string {
+	if name, ok := orderStatusNames[status]; ok {
+		return name
+	}
+	return "未知状态"
+}
+
+// IsValidOrderStatus 判断是否为已定义的订单状态
+func IsValidOrderStatus(status int8) bool {
+	_, ok := orderStatusNames[status]
+	return ok
+}
+
+// NextOrderStatuses 返回指定状态允许流转到的状态列表
+func NextOrderStatuses(status int8) []int8 {
+	next := orderStatusTransitions[status]
+	result := make([]int8, len(next))
+	copy(result, next)
+	return result
+}
+
+// CanTransitionOrderStatus 判断订单状态能否从 from 流转到 to
+func CanTransitionOrderStatus(from, to int8) bool {
+	for _, next := range orderStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderStatusHistory 订单状态流转记录
+type OrderStatusHistory struct {
+	ID           uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt    time.Time `json:"created_at"`
+	OrderID      uint64    `json:"order_id" gorm:"index;not null"`
+	FromStatus   int8      `json:"from_status"`
+	ToStatus     int8      `json:"to_status"`
+	OperatorID   uint      `json:"operator_id" gorm:"index"`
+	OperatorName string    `json:"operator_name" gorm:"size:64"`
+	Remark       string    `json:"remark" gorm:"size:255"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
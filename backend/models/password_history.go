@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// PasswordHistory 用户历史密码哈希，用于禁止重复使用最近的密码
+type PasswordHistory struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt time.Time `json:"created_at"`
+
+	UserID       uint   `json:"user_id" gorm:"index;not null"`
+	PasswordHash string `json:"-" gorm:"size:255;not null"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_histories"
+}
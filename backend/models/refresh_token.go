@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken 刷新令牌（仅保存哈希值），同一次登录轮换出的令牌属于同一个 Family
+type RefreshToken struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	UserID        uint       `json:"user_id" gorm:"index;not null"`
+	FamilyID      string     `json:"family_id" gorm:"size:64;index;not null"`
+	TokenHash     string     `json:"-" gorm:"size:64;uniqueIndex;not null"`
+	UserAgentHash string     `json:"-" gorm:"size:64"`
+	IP            string     `json:"ip" gorm:"size:64"`
+	ExpiresAt     time.Time  `json:"expires_at" gorm:"index"`
+	UsedAt        *time.Time `json:"used_at"`    // 已轮换使用的时间，再次出现即视为重放
+	RevokedAt     *time.Time `json:"revoked_at"` // 被吊销的时间
+}
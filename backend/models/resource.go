@@ -13,8 +13,8 @@ type Resource struct {
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
 	Name        string     `json:"name" gorm:"type:varchar(255);not null"`
-	Path        string     `json:"path" gorm:"type:varchar(255);uniqueIndex;not null"`
-	Method      string     `json:"method" gorm:"not null"` // GET, POST, PUT, DELETE
+	Path        string     `json:"path" gorm:"type:varchar(255);uniqueIndex:idx_resources_path_method;not null"`
+	Method      string     `json:"method" gorm:"type:varchar(16);uniqueIndex:idx_resources_path_method;not null"` // GET, POST, PUT, DELETE
 	Description string     `json:"description"`
 	Type        string     `json:"type"` // api, menu, button
 	ParentID    *uint      `json:"parent_id" gorm:"index"` // 父资源ID，nil表示顶级资源
@@ -6,14 +6,35 @@ import (
 	"gorm.io/gorm"
 )
 
+// 角色数据范围，决定订单、素材等业务数据的可见范围
+const (
+	DataScopeSelf     = "self"      // 仅本人创建的数据
+	DataScopeDept     = "dept"      // 本部门成员创建的数据
+	DataScopeDeptTree = "dept_tree" // 本部门及下级部门成员创建的数据
+	DataScopeAll      = "all"       // 全部数据
+)
+
+// NormalizeDataScope 规范化数据范围取值，未知取值按仅本人处理
+func NormalizeDataScope(scope string) string {
+	switch scope {
+	case DataScopeDept, DataScopeDeptTree, DataScopeAll:
+		return scope
+	default:
+		return DataScopeSelf
+	}
+}
+
 type Role struct {
 	ID        uint           `json:"id" gorm:"primarykey"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
 
-	Name        string `json:"name" gorm:"type:varchar(255);uniqueIndex;not null"`
-	Description string `json:"description"`
+	Name         string `json:"name" gorm:"type:varchar(255);uniqueIndex;not null"`
+	Description  string `json:"description"`
+	RequireMFA   bool   `json:"require_mfa" gorm:"column:require_mfa;default:false"`       // 该角色用户登录必须通过二次验证
+	DataScope    string `json:"data_scope" gorm:"size:16;default:'self'"`                  // 数据范围：self/dept/dept_tree/all
+	IsSuperAdmin bool   `json:"is_super_admin" gorm:"column:is_super_admin;default:false"` // 超级管理员：跳过接口权限校验并可见全部数据
 
 	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;"`
 }
@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Shein 同步记录状态
+const (
+	SheinSyncRunning   = "running"   // 同步中
+	SheinSyncSucceeded = "succeeded" // 同步成功
+	SheinSyncPartial   = "partial"   // 部分订单保存失败
+	SheinSyncFailed    = "failed"    // 同步失败
+)
+
+// Shein 同步触发方式
+const (
+	SheinSyncTriggerSchedule = "schedule" // 定时任务
+	SheinSyncTriggerManual   = "manual"   // 手动触发
+)
+
+// SheinSyncState 各店铺的增量同步水位
+type SheinSyncState struct {
+	ID        uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ShopCode  string     `json:"shop_code" gorm:"size:32;not null;uniqueIndex"`
+	Watermark *time.Time `json:"watermark"`   // 已完整同步到的时间，下次从该时间（减去重叠窗口）开始
+	LastRunID uint64     `json:"last_run_id"` // 最近一次同步记录
+}
+
+// SheinSyncRun Shein 订单同步记录
+type SheinSyncRun struct {
+	ID          uint64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ShopCode    string     `json:"shop_code" gorm:"size:32;index"`
+	Trigger     string     `json:"trigger" gorm:"column:trigger_type;size:16;not null"`
+	Status      string     `json:"status" gorm:"size:16;not null;index"`
+	WindowStart time.Time  `json:"window_start"` // 拉取的订单时间范围
+	WindowEnd   time.Time  `json:"window_end"`
+	Total       int        `json:"total"`
+	Created     int        `json:"created"`
+	Updated     int        `json:"updated"`
+	Skipped     int        `json:"skipped"`
+	Failed      int        `json:"failed"`
+	Message     string     `json:"message" gorm:"size:512"`
+	Errors      string     `json:"-" gorm:"type:longtext"` // 失败订单明细（JSON）
+	CreatedBy   uint       `json:"created_by"`             // 手动触发的用户，定时任务为 0
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at"`
+}
+
+// SheinSyncFailure 增量同步中获取或保存失败的订单，后续同步按订单号单独重试；
+// 连续失败达到上限后标记为 dead，不再自动重试
+type SheinSyncFailure struct {
+	ID        uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ShopCode  string    `json:"shop_code" gorm:"size:32;not null;uniqueIndex:idx_shein_sync_failures_order"`
+	OrderNo   string    `json:"order_no" gorm:"size:64;not null;uniqueIndex:idx_shein_sync_failures_order"`
+	Attempts  int       `json:"attempts"`                                 // 已失败次数
+	Dead      bool      `json:"dead" gorm:"not null;default:false;index"` // 超过重试上限，需人工处理
+	Message   string    `json:"message" gorm:"size:512"`                  // 最近一次失败原因
+	LastRunID uint64    `json:"last_run_id"`                              // 最近一次失败的同步记录
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// 店铺凭证的接口环境
+const (
+	ShopEnvProd = "prod" // 正式环境
+	ShopEnvTest = "test" // 测试环境
+)
+
+// ShopCredential 店铺的 Shein 开放平台凭证，店铺编号与订单的 shop_code 对应
+type ShopCredential struct {
+	ID          uint64    `json:"id" gorm:"primaryKey;autoIncrement"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	ShopCode    string    `json:"shop_code" gorm:"size:32;not null;uniqueIndex"`
+	Name        string    `json:"name" gorm:"size:64"`
+	AppKey      string    `json:"app_key" gorm:"size:128;not null"`
+	AppSecret   string    `json:"-" gorm:"size:512;not null"` // 加密后的密钥，不返回给前端
+	Environment string    `json:"environment" gorm:"size:16;not null;default:test"`
+	BaseURL     string    `json:"base_url" gorm:"size:255"` // 为空时按环境使用默认地址
+	Enabled     bool      `json:"enabled" gorm:"index"`
+	Remark      string    `json:"remark" gorm:"size:255"`
+	CreatedBy   uint      `json:"created_by"`
+}
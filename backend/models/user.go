@@ -22,4 +22,11 @@ type User struct {
 	DepartmentID uint        `json:"department_id"`
 	Department   *Department `json:"department,omitempty" gorm:"foreignKey:DepartmentID"`
 	EmployeeType string      `json:"employee_type" gorm:"size:32;not null;default:internal"`
+
+	PasswordChangedAt  *time.Time `json:"password_changed_at"`
+	MustChangePassword bool       `json:"must_change_password" gorm:"default:false"` // 下次登录必须修改密码
+
+	TOTPSecret        string `json:"-" gorm:"column:totp_secret;size:128"`
+	TOTPEnabled       bool   `json:"totp_enabled" gorm:"column:totp_enabled;default:false"`
+	TOTPRecoveryCodes string `json:"-" gorm:"column:totp_recovery_codes;type:text"` // 恢复码哈希（JSON数组）
 }
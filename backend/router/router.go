@@ -13,6 +13,9 @@ func SetupRoutes(r *gin.Engine) {
 	{
 		// 认证相关
 		api.POST("/auth/login", controllers.Login)
+		api.POST("/auth/refresh", controllers.RefreshToken)
+		api.POST("/auth/mfa/setup", controllers.SetupMFAChallenge)
+		api.POST("/auth/mfa/verify", controllers.VerifyMFAChallenge)
 		api.GET("/auth/captcha", controllers.GetCaptcha)
 
 		// 根据代码获取字典（公开接口，用于前端下拉选择等）
@@ -21,22 +24,22 @@ func SetupRoutes(r *gin.Engine) {
 		// 根据键获取系统参数（公开接口）
 		api.GET("/config/:key", controllers.GetConfigByKey)
 
-		// Shein API 相关接口（公开，用于测试）
-		shein := api.Group("/shein")
-		{
-			sheinController := controllers.NewSheinController()
-			shein.GET("/sync-orders", sheinController.SyncOrders)
-			shein.GET("/order-list", sheinController.GetOrderList)
-			shein.GET("/order-detail", sheinController.GetOrderDetail)
-		}
+		sheinController := controllers.NewSheinController()
 
 		// 需要认证的路由
 		auth := api.Group("")
 		auth.Use(middleware.AuthMiddleware())
 		auth.Use(middleware.LogMiddleware())
+		auth.Use(middleware.PermissionMiddleware())
 		{
 			// 用户信息
 			auth.GET("/auth/current-user", controllers.GetCurrentUser)
+			auth.POST("/auth/logout", controllers.Logout)
+			auth.GET("/auth/sessions", controllers.ListMySessions)
+			auth.DELETE("/auth/sessions/:id", controllers.DeleteMySession)
+			auth.POST("/auth/mfa/enroll", controllers.EnrollMFA)
+			auth.POST("/auth/mfa/enable", controllers.EnableMFA)
+			auth.POST("/auth/mfa/disable", controllers.DisableMFA)
 			auth.PUT("/auth/change-password", controllers.ChangePassword)
 
 			// 用户管理
@@ -45,6 +48,12 @@ func SetupRoutes(r *gin.Engine) {
 			auth.POST("/users", controllers.CreateUser)
 			auth.PUT("/users/:id", controllers.UpdateUser)
 			auth.DELETE("/users/:id", controllers.DeleteUser)
+			auth.POST("/users/:id/unlock", controllers.UnlockUserLogin)
+			auth.POST("/users/:id/mfa/reset", controllers.ResetUserMFA)
+
+			// 登录会话管理
+			auth.GET("/sessions", controllers.ListSessions)
+			auth.DELETE("/sessions/:id", controllers.DeleteSession)
 
 			// 角色管理
 			auth.GET("/roles", controllers.GetRoles)
@@ -129,8 +138,35 @@ func SetupRoutes(r *gin.Engine) {
 			auth.POST("/orders", controllers.CreateOrder)
 			auth.PUT("/orders/:id", controllers.UpdateOrder)
 			auth.DELETE("/orders/:id", controllers.DeleteOrder)
+			auth.POST("/orders/:id/transition", controllers.TransitionOrder)
+			auth.POST("/orders/bulk", controllers.BulkOrders)
+			auth.POST("/orders/shipping-labels/merge", controllers.MergeShippingLabels)
+			auth.GET("/shein/order-list", sheinController.GetOrderList)
+			auth.GET("/shein/order-detail", sheinController.GetOrderDetail)
+			auth.POST("/shein/sync-orders", sheinController.SyncOrders)
+			auth.GET("/shein/sync-runs", sheinController.GetSyncRuns)
+			auth.GET("/shein/sync-runs/:id", sheinController.GetSyncRun)
+			auth.POST("/shein/sync-runs", sheinController.TriggerSyncRun)
+			auth.GET("/shein/sync-states", sheinController.GetSyncStates)
+
+			// 店铺凭证
+			auth.GET("/shop-credentials", controllers.ListShopCredentials)
+			auth.GET("/shop-credentials/:id", controllers.GetShopCredential)
+			auth.POST("/shop-credentials", controllers.CreateShopCredential)
+			auth.PUT("/shop-credentials/:id", controllers.UpdateShopCredential)
+			auth.DELETE("/shop-credentials/:id", controllers.DeleteShopCredential)
+			auth.GET("/orders/:id/status-history", controllers.GetOrderStatusHistory)
 			auth.POST("/orders/import", controllers.ImportOrders)
+			auth.GET("/import-jobs/:id", controllers.GetImportJob)
+			auth.GET("/import-jobs/:id/error-report", controllers.DownloadImportJobReport)
+			auth.GET("/import-profiles", controllers.ListImportProfiles)
+			auth.GET("/import-profiles/:id", controllers.GetImportProfile)
+			auth.POST("/import-profiles", controllers.CreateImportProfile)
+			auth.PUT("/import-profiles/:id", controllers.UpdateImportProfile)
+			auth.DELETE("/import-profiles/:id", controllers.DeleteImportProfile)
 			auth.GET("/orders/export", controllers.ExportOrders)
+			auth.GET("/orders/export/columns", controllers.GetOrderExportColumns)
+			auth.GET("/orders/export/bundle", controllers.ExportOrderBundle)
 
 			// 存储设置
 			auth.GET("/storage/settings", controllers.GetStorageSettings)
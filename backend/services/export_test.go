@@ -0,0 +1,15 @@
+package services
+
+import "time"
+
+// SetSheinRetryPolicyForTest 缩短重试与熔断的等待时间，返回恢复原设置的函数
+func SetSheinRetryPolicyForTest(maxRetries int, retryBase, retryMaxWait time.Duration, threshold int, cooldown time.Duration) func() {
+	oldRetries, oldBase, oldMaxWait := sheinMaxRetries, sheinRetryBase, sheinRetryMaxWait
+	oldThreshold, oldCooldown := sheinBreakerThreshold, sheinBreakerCooldown
+	sheinMaxRetries, sheinRetryBase, sheinRetryMaxWait = maxRetries, retryBase, retryMaxWait
+	sheinBreakerThreshold, sheinBreakerCooldown = threshold, cooldown
+	return func() {
+		sheinMaxRetries, sheinRetryBase, sheinRetryMaxWait = oldRetries, oldBase, oldMaxWait
+		sheinBreakerThreshold, sheinBreakerCooldown = oldThreshold, oldCooldown
+	}
+}
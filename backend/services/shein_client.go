@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// sheinRateLimit 每个店铺每秒最多发起的请求数，sheinRateBurst 为允许的突发请求数
+	sheinRateLimit      = 5
+	sheinRateBurst      = 5
+	sheinRequestTimeout = 30 * time.Second
+	// sheinDetailConcurrency 并发获取订单详情的数量
+	sheinDetailConcurrency = 5
+)
+
+// 重试与熔断参数，测试中可缩短等待时间
+var (
+	// sheinMaxRetries 遇到 429、5xx 或网络错误时的最大重试次数
+	sheinMaxRetries   = 4
+	sheinRetryBase    = 500 * time.Millisecond
+	sheinRetryMaxWait = 15 * time.Second
+	// 连续失败 sheinBreakerThreshold 次后熔断，sheinBreakerCooldown 后放行一次试探请求
+	sheinBreakerThreshold = 5
+	sheinBreakerCooldown  = 30 * time.Second
+)
+
+// ErrSheinCircuitOpen Shein 接口连续失败，暂停请求
+var ErrSheinCircuitOpen = errors.New("Shein 接口连续请求失败，已暂停调用，请稍后再试")
+
+// sheinHTTPClient 所有店铺共用的 HTTP 客户端
+var sheinHTTPClient = &http.Client{Timeout: sheinRequestTimeout}
+
+// sheinEndpoint 同一接口地址与 openKeyId 共用的限流器与熔断状态
+type sheinEndpoint struct {
+	limiter *rate.Limiter
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+var (
+	sheinEndpointsMu sync.Mutex
+	sheinEndpoints   = make(map[string]*sheinEndpoint)
+)
+
+func getSheinEndpoint(baseURL, openKeyID string) *sheinEndpoint {
+	key := baseURL + "|" + openKeyID
+	sheinEndpointsMu.Lock()
+	defer sheinEndpointsMu.Unlock()
+	endpoint, ok := sheinEndpoints[key]
+	if !ok {
+		endpoint = &sheinEndpoint{limiter: rate.NewLimiter(sheinRateLimit, sheinRateBurst)}
+		sheinEndpoints[key] = endpoint
+	}
+	return endpoint
+}
+
+// allow 熔断期间拒绝请求；冷却结束后只放行一个试探请求，其成功后恢复
+func (e *sheinEndpoint) allow() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.failures < sheinBreakerThreshold {
+		return true
+	}
+	if time.Now().Before(e.openUntil) || e.probing {
+		return false
+	}
+	e.probing = true
+	return true
+}
+
+// abort 请求未完成（如 context 取消）时释放试探名额，不影响熔断计数
+func (e *sheinEndpoint) abort() {
+	e.mu.Lock()
+	e.probing = false
+	e.mu.Unlock()
+}
+
+func (e *sheinEndpoint) record(success bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.probing = false
+	if success {
+		e.failures = 0
+		return
+	}
+	e.failures++
+	if e.failures >= sheinBreakerThreshold {
+		e.openUntil = time.Now().Add(sheinBreakerCooldown)
+	}
+}
+
+// sheinRetryableError 可重试的失败，retryAfter 为服务端要求的等待时间
+type sheinRetryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *sheinRetryableError) Error() string { return e.err.Error() }
+
+// do 发送请求并返回响应体：经过限流与熔断，429、5xx 与网络错误按指数退避加随机抖动重试。
+// newRequest 每次重试重新构建请求，以便重新签名
+func (s *SheinService) do(ctx context.Context, newRequest func() (*http.Request, error)) ([]byte, error) {
+	endpoint := getSheinEndpoint(s.BaseURL, s.AppKey)
+	var lastErr error
+	for attempt := 0; attempt <= sheinMaxRetries; attempt++ {
+		if attempt > 0 {
+			wait := sheinBackoff(attempt)
+			var retryable *sheinRetryableError
+			if errors.As(lastErr, &retryable) && retryable.retryAfter > wait {
+				wait = retryable.retryAfter
+			}
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		if !endpoint.allow() {
+			return nil, ErrSheinCircuitOpen
+		}
+		if err := endpoint.limiter.Wait(ctx); err != nil {
+			endpoint.abort()
+			return nil, err
+		}
+
+		body, err := s.doOnce(ctx, newRequest)
+		if err == nil {
+			endpoint.record(true)
+			return body, nil
+		}
+		if ctx.Err() != nil {
+			endpoint.abort()
+			return nil, ctx.Err()
+		}
+
+		var retryable *sheinRetryableError
+		if !errors.As(err, &retryable) {
+			// 业务错误或 4xx 说明接口可用，不计入熔断
+			endpoint.record(true)
+			return nil, err
+		}
+		endpoint.record(false)
+		lastErr = err
+	}
+	return nil, fmt.Errorf("重试 %d 次后仍失败: %w", sheinMaxRetries, lastErr)
+}
+
+func (s *SheinService) doOnce(ctx context.Context, newRequest func() (*http.Request, error)) ([]byte, error) {
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := sheinHTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, &sheinRetryableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &sheinRetryableError{err: err}
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError {
+		return nil, &sheinRetryableError{
+			err:        sheinStatusError(resp.StatusCode, data),
+			retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, sheinStatusError(resp.StatusCode, data)
+	}
+	return data, nil
+}
+
+// sheinBackoff 第 attempt 次重试前的等待时间：按指数增长，在其一半到全部之间随机取值，避免多个请求同时重试
+func sheinBackoff(attempt int) time.Duration {
+	wait := sheinRetryBase << (attempt - 1)
+	if wait > sheinRetryMaxWait || wait <= 0 {
+		wait = sheinRetryMaxWait
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// sheinStatusError 非 200 响应的错误，优先使用响应中的 message
+func sheinStatusError(status int, data []byte) error {
+	var result struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &result) == nil && result.Message != "" {
+		return fmt.Errorf("HTTP %d: %s", status, result.Message)
+	}
+	return fmt.Errorf("HTTP %d", status)
+}
+
+// parseRetryAfter 解析 Retry-After 响应头（秒数），无法解析时返回 0
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	wait := time.Duration(seconds) * time.Second
+	if wait > sheinRetryMaxWait {
+		wait = sheinRetryMaxWait
+	}
+	return wait
+}
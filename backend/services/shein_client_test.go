@@ -0,0 +1,205 @@
+package services_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"haodun_manage/backend/services"
+	"haodun_manage/backend/services/sheinmock"
+)
+
+// newMockWithOrders 启动模拟服务并添加 n 个一小时前更新的订单
+func newMockWithOrders(n int) *sheinmock.Server {
+	srv := sheinmock.New("test-key", "test-secret")
+	updated := time.Now().Add(-time.Hour).Format(services.SheinTimeLayout)
+	for i := 0; i < n; i++ {
+		srv.AddOrders(services.OrderDetail{
+			OrderNo:    fmt.Sprintf("GSP%03d", i),
+			UpdateTime: updated,
+			Items:      []services.OrderDetailItem{{Sku: "SKU1", Quantity: 1}},
+		})
+	}
+	return srv
+}
+
+func syncWindow() (string, string) {
+	now := time.Now()
+	return now.Add(-2 * time.Hour).Format(services.SheinTimeLayout), now.Format(services.SheinTimeLayout)
+}
+
+func TestGetAllOrdersPaginates(t *testing.T) {
+	srv := newMockWithOrders(65)
+	defer srv.Close()
+
+	start, end := syncWindow()
+	orderNos, err := srv.Service("S1").GetAllOrders(context.Background(), start, end, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orderNos) != 65 {
+		t.Fatalf("got %d orders, want 65", len(orderNos))
+	}
+	if orderNos[0] != "GSP000" || orderNos[64] != "GSP064" {
+		t.Fatalf("unexpected order numbers %s..%s", orderNos[0], orderNos[64])
+	}
+	// 每页 30 条，65 条需 3 页
+	if n := srv.Requests(services.OrderListPath); n != 3 {
+		t.Fatalf("got %d list requests, want 3", n)
+	}
+}
+
+func TestGetAllOrdersAPIError(t *testing.T) {
+	srv := newMockWithOrders(5)
+	defer srv.Close()
+	srv.FailCode(services.OrderListPath, 30001, "店铺无权限", 1)
+
+	start, end := syncWindow()
+	_, err := srv.Service("S1").GetAllOrders(context.Background(), start, end, "")
+	if err == nil || !strings.Contains(err.Error(), "店铺无权限") {
+		t.Fatalf("err = %v, want API error", err)
+	}
+}
+
+func TestGetOrderDetailsCollectsPerOrderErrors(t *testing.T) {
+	srv := newMockWithOrders(3)
+	defer srv.Close()
+
+	details, failures := srv.Service("S1").GetOrderDetails(context.Background(), []string{"GSP000", "MISSING", "GSP002"})
+	if len(details) != 2 || details[0].Data.OrderNo != "GSP000" || details[1].Data.OrderNo != "GSP002" {
+		t.Fatalf("unexpected details %+v", details)
+	}
+	if len(failures) != 1 || failures[0].OrderNo != "MISSING" || !strings.Contains(failures[0].Err.Error(), "订单不存在") {
+		t.Fatalf("unexpected failures %+v", failures)
+	}
+}
+
+func TestRetryStopsAfterMaxAttempts(t *testing.T) {
+	defer services.SetSheinRetryPolicyForTest(2, time.Millisecond, 5*time.Millisecond, 100, time.Minute)()
+
+	for _, status := range []int{http.StatusTooManyRequests, http.StatusServiceUnavailable} {
+		srv := newMockWithOrders(1)
+		srv.FailHTTP(services.OrderListPath, status, 10)
+
+		start, end := syncWindow()
+		_, err := srv.Service("S1").GetAllOrders(context.Background(), start, end, "")
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("HTTP %d", status)) {
+			t.Errorf("HTTP %d: err = %v", status, err)
+		}
+		// 首次请求加 2 次重试
+		if n := srv.Requests(services.OrderListPath); n != 3 {
+			t.Errorf("HTTP %d: got %d requests, want 3", status, n)
+		}
+		srv.Close()
+	}
+}
+
+func TestRetryRecoversFromTransientFailure(t *testing.T) {
+	defer services.SetSheinRetryPolicyForTest(2, time.Millisecond, 5*time.Millisecond, 100, time.Minute)()
+	srv := newMockWithOrders(1)
+	defer srv.Close()
+	srv.FailHTTP(services.OrderListPath, http.StatusBadGateway, 1)
+
+	start, end := syncWindow()
+	orderNos, err := srv.Service("S1").GetAllOrders(context.Background(), start, end, "")
+	if err != nil || len(orderNos) != 1 {
+		t.Fatalf("got %v, %v", orderNos, err)
+	}
+	if n := srv.Requests(services.OrderListPath); n != 2 {
+		t.Fatalf("got %d requests, want 2", n)
+	}
+}
+
+func TestClientErrorsAreNotRetried(t *testing.T) {
+	defer services.SetSheinRetryPolicyForTest(3, time.Millisecond, 5*time.Millisecond, 100, time.Minute)()
+	srv := newMockWithOrders(1)
+	defer srv.Close()
+	service := srv.Service("S1")
+	start, end := syncWindow()
+
+	srv.FailHTTP(services.OrderListPath, http.StatusBadRequest, 5)
+	if _, err := service.GetAllOrders(context.Background(), start, end, ""); err == nil {
+		t.Fatal("expected HTTP 400 error")
+	}
+	if n := srv.Requests(services.OrderListPath); n != 1 {
+		t.Fatalf("HTTP 400: got %d requests, want 1", n)
+	}
+
+	srv.FailCode(services.OrderDetailPath, 30001, "限流", 5)
+	if _, failures := service.GetOrderDetails(context.Background(), []string{"GSP000"}); len(failures) != 1 {
+		t.Fatal("expected API error")
+	}
+	if n := srv.Requests(services.OrderDetailPath); n != 1 {
+		t.Fatalf("API error: got %d requests, want 1", n)
+	}
+}
+
+func TestCircuitBreakerOpensAndProbes(t *testing.T) {
+	const cooldown = 100 * time.Millisecond
+	defer services.SetSheinRetryPolicyForTest(0, time.Millisecond, time.Millisecond, 3, cooldown)()
+	srv := newMockWithOrders(1)
+	defer srv.Close()
+	service := srv.Service("S1")
+	start, end := syncWindow()
+	ctx := context.Background()
+
+	srv.FailHTTP(services.OrderListPath, http.StatusInternalServerError, 4)
+	for i := 0; i < 3; i++ {
+		if _, err := service.GetAllOrders(ctx, start, end, ""); errors.Is(err, services.ErrSheinCircuitOpen) {
+			t.Fatalf("call %d: breaker opened too early", i+1)
+		}
+	}
+	// 连续失败 3 次后熔断，请求不再发出
+	if _, err := service.GetAllOrders(ctx, start, end, ""); !errors.Is(err, services.ErrSheinCircuitOpen) {
+		t.Fatalf("err = %v, want ErrSheinCircuitOpen", err)
+	}
+	if n := srv.Requests(services.OrderListPath); n != 3 {
+		t.Fatalf("got %d requests while open, want 3", n)
+	}
+
+	// 冷却后放行一次试探请求，试探失败立即重新熔断
+	time.Sleep(cooldown + 20*time.Millisecond)
+	if _, err := service.GetAllOrders(ctx, start, end, ""); err == nil || errors.Is(err, services.ErrSheinCircuitOpen) {
+		t.Fatalf("probe: err = %v, want HTTP 500", err)
+	}
+	if _, err := service.GetAllOrders(ctx, start, end, ""); !errors.Is(err, services.ErrSheinCircuitOpen) {
+		t.Fatalf("after failed probe: err = %v, want ErrSheinCircuitOpen", err)
+	}
+	if n := srv.Requests(services.OrderListPath); n != 4 {
+		t.Fatalf("got %d requests, want 4", n)
+	}
+
+	// 试探成功后恢复
+	time.Sleep(cooldown + 20*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := service.GetAllOrders(ctx, start, end, ""); err != nil {
+			t.Fatalf("after recovery call %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestCancelledContextStopsBackoff(t *testing.T) {
+	defer services.SetSheinRetryPolicyForTest(3, 10*time.Second, 10*time.Second, 100, time.Minute)()
+	srv := newMockWithOrders(1)
+	defer srv.Close()
+	srv.FailHTTP(services.OrderListPath, http.StatusServiceUnavailable, 5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start, end := syncWindow()
+	began := time.Now()
+	_, err := srv.Service("S1").GetAllOrders(ctx, start, end, "")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(began); elapsed > 2*time.Second {
+		t.Fatalf("backoff was not interrupted, took %s", elapsed)
+	}
+	if n := srv.Requests(services.OrderListPath); n != 1 {
+		t.Fatalf("got %d requests, want 1", n)
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"haodun_manage/backend/models"
+)
+
+// SheinTimeLayout Shein 接口使用的时间格式
+const SheinTimeLayout = "2006-01-02 15:04:05"
+
+// ToOrderInfo 将订单详情转换为平台面单订单。一个 Shein 订单对应一条记录：
+// 商品信息取第一个商品，件数为全部商品数量之和，其余商品记入特殊产品备注
+func (r *OrderDetailResponse) ToOrderInfo() (*models.OrderInfo, error) {
+	detail := r.Data
+	orderNo := strings.TrimSpace(detail.OrderNo)
+	if orderNo == "" {
+		return nil, errors.New("订单号为空")
+	}
+
+	order := &models.OrderInfo{
+		GSPOrderNo:       truncateRunes(orderNo, 32),
+		OrderType:        "platform",
+		ExpectedRevenue:  detail.OrderAmount,
+		CurrencyCode:     truncateRunes(strings.ToUpper(strings.TrimSpace(detail.Currency)), 16),
+		CustomerFullName: truncateRunes(strings.TrimSpace(detail.ShippingAddress.Name), 128),
+		PhoneNumber:      truncateRunes(strings.TrimSpace(detail.ShippingAddress.Phone), 32),
+		AddressLine1:     truncateRunes(strings.TrimSpace(detail.ShippingAddress.Address), 128),
+	}
+
+	if value := strings.TrimSpace(detail.CreateTime); value != "" {
+		createdAt, err := time.ParseInLocation(SheinTimeLayout, value, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("订单创建时间格式错误: %s", value)
+		}
+		order.OrderCreatedAt = createdAt
+	}
+
+	quantity := 0
+	var others []string
+	for i, item := range detail.Items {
+		quantity += item.Quantity
+		if i == 0 {
+			order.SellerSKU = truncateRunes(strings.TrimSpace(item.Sku), 64)
+			order.ProductName = truncateRunes(strings.TrimSpace(item.ProductName), 128)
+			order.ProductPrice = item.Price
+			continue
+		}
+		others = append(others, fmt.Sprintf("%s×%d", strings.TrimSpace(item.Sku), item.Quantity))
+	}
+	if quantity > 0 {
+		order.ExpectedFulfillmentQty = quantity
+		order.ItemCount = quantity
+	}
+	if len(others) > 0 {
+		order.SpecialProductNote = truncateRunes("另含商品："+strings.Join(others, "，"), 200)
+	}
+	return order, nil
+}
+
+// truncateRunes 按字符数截断，避免超出数据库列宽
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
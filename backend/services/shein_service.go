@@ -1,28 +1,28 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
-	"net/url"
-	"os"
-	"strconv"
-	"time"
-
-	"haodun_manage/backend/utils"
+	"strings"
+	"sync"
 )
 
 const (
-	// SheinBaseURL = "https://openapi.sheincorp.com"
-	SheinBaseURL    = "https://openapi-test01.sheincorp.cn"
-	OrderListPath   = "/open-api/order/order-list"
-	OrderDetailPath = "/open-api/order/order-detail"
+	SheinProdBaseURL = "https://openapi.sheincorp.com"
+	SheinTestBaseURL = "https://openapi-test01.sheincorp.cn"
+	OrderListPath    = "/open-api/order/order-list"
+	OrderDetailPath  = "/open-api/order/order-detail"
 )
 
+// SheinService 单个店铺的 Shein 开放平台客户端，AppKey、AppSecret 即开放平台的 openKeyId 与 secretKey
 type SheinService struct {
+	ShopCode  string
 	AppKey    string
 	AppSecret string
+	BaseURL   string
 }
 
 type OrderListRequest struct {
@@ -46,56 +46,64 @@ type OrderListResponse struct {
 	Message string `json:"message"`
 }
 
-type OrderDetailResponse struct {
-	Code int `json:"code"`
-	Data struct {
-		OrderNo     string  `json:"order_no"`
-		OrderStatus int     `json:"order_status"`
-		OrderAmount float64 `json:"order_amount"`
-		Currency    string  `json:"currency"`
-		CreateTime  string  `json:"create_time"`
-		UpdateTime  string  `json:"update_time"`
-		Items       []struct {
-			Sku         string  `json:"sku"`
-			Quantity    int     `json:"quantity"`
-			Price       float64 `json:"price"`
-			ProductName string  `json:"product_name"`
-		} `json:"items"`
-		ShippingAddress struct {
-			Name    string `json:"name"`
-			Phone   string `json:"phone"`
-			Address string `json:"address"`
-		} `json:"shipping_address"`
-	} `json:"data"`
-	Message string `json:"message"`
+// OrderDetailItem 订单中的商品
+type OrderDetailItem struct {
+	Sku         string  `json:"sku"`
+	Quantity    int     `json:"quantity"`
+	Price       float64 `json:"price"`
+	ProductName string  `json:"product_name"`
 }
 
-// NewSheinService 创建Shein服务实例，优先从环境变量读取配置
-func NewSheinService() *SheinService {
-	appKey := os.Getenv("SHEIN_APP_KEY")
-	appSecret := os.Getenv("SHEIN_APP_SECRET")
+// OrderShippingAddress 订单收货地址
+type OrderShippingAddress struct {
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Address string `json:"address"`
+}
 
-	if appKey == "" || appSecret == "" {
-		// 测试用的默认值（请替换为实际值）
-		appKey = "your_test_app_key"
-		appSecret = "your_test_app_secret"
-		fmt.Println("警告：使用默认测试配置，请在生产环境中设置环境变量")
-	}
+// OrderDetail 订单详情
+type OrderDetail struct {
+	OrderNo         string               `json:"order_no"`
+	OrderStatus     int                  `json:"order_status"`
+	OrderAmount     float64              `json:"order_amount"`
+	Currency        string               `json:"currency"`
+	CreateTime      string               `json:"create_time"`
+	UpdateTime      string               `json:"update_time"`
+	Items           []OrderDetailItem    `json:"items"`
+	ShippingAddress OrderShippingAddress `json:"shipping_address"`
+}
+
+type OrderDetailRequest struct {
+	OrderNo string `json:"order_no"`
+}
 
+type OrderDetailResponse struct {
+	Code    int         `json:"code"`
+	Data    OrderDetail `json:"data"`
+	Message string      `json:"message"`
+}
+
+// NewSheinService 创建店铺的 Shein 服务实例，baseURL 为空时使用测试环境地址
+func NewSheinService(shopCode, appKey, appSecret, baseURL string) *SheinService {
+	if baseURL == "" {
+		baseURL = SheinTestBaseURL
+	}
 	return &SheinService{
+		ShopCode:  shopCode,
 		AppKey:    appKey,
 		AppSecret: appSecret,
+		BaseURL:   strings.TrimRight(baseURL, "/"),
 	}
 }
 
 // GetAllOrders 获取全部订单（自动分页）
-func (s *SheinService) GetAllOrders(startTime, endTime string, status string) ([]string, error) {
+func (s *SheinService) GetAllOrders(ctx context.Context, startTime, endTime string, status string) ([]string, error) {
 	var allOrders []string
 	page := 1
 	pageSize := 30
 
 	for {
-		orders, total, err := s.getOrderListPage(startTime, endTime, status, page, pageSize)
+		orders, total, err := s.getOrderListPage(ctx, startTime, endTime, status, page, pageSize)
 		if err != nil {
 			return nil, err
 		}
@@ -104,80 +112,78 @@ func (s *SheinService) GetAllOrders(startTime, endTime string, status string) ([
 			allOrders = append(allOrders, order.OrderNo)
 		}
 
-		// 检查是否获取完所有数据
-		if len(allOrders) >= total {
+		// 检查是否获取完所有数据，返回空页时同样结束，避免总数变化导致死循环
+		if len(allOrders) >= total || len(orders) == 0 {
 			break
 		}
 
 		page++
-		// 避免请求过快
-		time.Sleep(100 * time.Millisecond)
 	}
 
 	return allOrders, nil
 }
 
-// GetOrderDetails 批量获取订单详情
-func (s *SheinService) GetOrderDetails(orderNos []string) ([]OrderDetailResponse, error) {
-	var details []OrderDetailResponse
+// OrderFetchError 获取单个订单详情失败
+type OrderFetchError struct {
+	OrderNo string
+	Err     error
+}
 
-	for _, orderNo := range orderNos {
-		detail, err := s.getOrderDetail(orderNo)
-		if err != nil {
-			return nil, fmt.Errorf("获取订单 %s 详情失败: %v", orderNo, err)
+// GetOrderDetails 并发获取订单详情，返回结果与 orderNos 顺序一致；
+// 单个订单失败不影响其他订单，失败的订单记入 failures
+func (s *SheinService) GetOrderDetails(ctx context.Context, orderNos []string) ([]OrderDetailResponse, []OrderFetchError) {
+	results := make([]*OrderDetailResponse, len(orderNos))
+	errs := make([]error, len(orderNos))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	workers := sheinDetailConcurrency
+	if len(orderNos) < workers {
+		workers = len(orderNos)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i], errs[i] = s.getOrderDetail(ctx, orderNos[i])
+			}
+		}()
+	}
+	for i := range orderNos {
+		if ctx.Err() != nil {
+			errs[i] = ctx.Err()
+			continue
 		}
-		details = append(details, *detail)
-
-		// 避免请求过快
-		time.Sleep(100 * time.Millisecond)
+		jobs <- i
 	}
+	close(jobs)
+	wg.Wait()
 
-	return details, nil
+	details := make([]OrderDetailResponse, 0, len(orderNos))
+	var failures []OrderFetchError
+	for i, orderNo := range orderNos {
+		if errs[i] != nil {
+			failures = append(failures, OrderFetchError{OrderNo: orderNo, Err: errs[i]})
+			continue
+		}
+		details = append(details, *results[i])
+	}
+	return details, failures
 }
 
 // getOrderListPage 获取单页订单列表
-func (s *SheinService) getOrderListPage(startTime, endTime, status string, page, pageSize int) ([]OrderListItem, int, error) {
-	params := map[string]string{
-		"start_time": startTime,
-		"end_time":   endTime,
-		"page":       strconv.Itoa(page),
-		"page_size":  strconv.Itoa(pageSize),
-	}
-
-	if status != "" {
-		params["status"] = status
-	}
-
-	// 添加公共参数
-	params["app_key"] = s.AppKey
-	params["timestamp"] = strconv.FormatInt(time.Now().Unix(), 10)
-
-	// 生成签名
-	signature := utils.SignUtil.SignRequest(params, s.AppSecret)
-	params["sign"] = signature
-
-	// 构建请求URL
-	u, _ := url.Parse(SheinBaseURL + OrderListPath)
-	q := u.Query()
-	for k, v := range params {
-		q.Set(k, v)
-	}
-	u.RawQuery = q.Encode()
-
-	// 发送请求
-	resp, err := http.Get(u.String())
-	if err != nil {
-		return nil, 0, err
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, 0, err
+func (s *SheinService) getOrderListPage(ctx context.Context, startTime, endTime, status string, page, pageSize int) ([]OrderListItem, int, error) {
+	req := OrderListRequest{
+		StartTime: startTime,
+		EndTime:   endTime,
+		Page:      page,
+		PageSize:  pageSize,
+		Status:    status,
 	}
 
 	var result OrderListResponse
-	if err := json.Unmarshal(body, &result); err != nil {
+	if err := s.post(ctx, OrderListPath, req, &result); err != nil {
 		return nil, 0, err
 	}
 
@@ -189,66 +195,58 @@ func (s *SheinService) getOrderListPage(startTime, endTime, status string, page,
 }
 
 // getOrderDetail 获取单个订单详情
-func (s *SheinService) getOrderDetail(orderNo string) (*OrderDetailResponse, error) {
-	params := map[string]string{
-		"order_no":  orderNo,
-		"app_key":   s.AppKey,
-		"timestamp": strconv.FormatInt(time.Now().Unix(), 10),
+func (s *SheinService) getOrderDetail(ctx context.Context, orderNo string) (*OrderDetailResponse, error) {
+	var result OrderDetailResponse
+	if err := s.post(ctx, OrderDetailPath, OrderDetailRequest{OrderNo: orderNo}, &result); err != nil {
+		return nil, err
 	}
 
-	// 生成签名
-	signature := utils.SignUtil.SignRequest(params, s.AppSecret)
-	params["sign"] = signature
-
-	// 构建请求URL
-	u, _ := url.Parse(SheinBaseURL + OrderDetailPath)
-	q := u.Query()
-	for k, v := range params {
-		q.Set(k, v)
+	if result.Code != 0 {
+		return nil, fmt.Errorf("API错误: %s", result.Message)
 	}
-	u.RawQuery = q.Encode()
 
-	// 发送请求
-	resp, err := http.Get(u.String())
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return &result, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// post 以 JSON 请求体调用开放平台接口，请求头携带签名，响应解析到 out
+func (s *SheinService) post(ctx context.Context, path string, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	var result OrderDetailResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
-	}
-
-	if result.Code != 0 {
-		return nil, fmt.Errorf("API错误: %s", result.Message)
+	signer := SheinSigner{OpenKeyID: s.AppKey, SecretKey: s.AppSecret}
+	data, err := s.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequest(http.MethodPost, s.BaseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json;charset=UTF-8")
+		if err := signer.SignRequest(req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return &result, nil
+	return json.Unmarshal(data, out)
 }
 
-// SyncOrders 同步全部订单（列表+详情）
-func (s *SheinService) SyncOrders(startTime, endTime string, status string) ([]OrderDetailResponse, error) {
+// SyncOrders 同步全部订单（列表+详情），获取列表失败时返回错误，获取详情失败的订单记入 failures
+func (s *SheinService) SyncOrders(ctx context.Context, startTime, endTime string, status string) ([]OrderDetailResponse, []OrderFetchError, error) {
 	// 1. 获取全部订单号
-	orderNos, err := s.GetAllOrders(startTime, endTime, status)
+	orderNos, err := s.GetAllOrders(ctx, startTime, endTime, status)
 	if err != nil {
-		return nil, fmt.Errorf("获取订单列表失败: %v", err)
+		return nil, nil, fmt.Errorf("获取订单列表失败: %v", err)
 	}
 
 	if len(orderNos) == 0 {
-		return []OrderDetailResponse{}, nil
+		return []OrderDetailResponse{}, nil, nil
 	}
 
 	// 2. 获取所有订单详情
-	details, err := s.GetOrderDetails(orderNos)
-	if err != nil {
-		return nil, fmt.Errorf("获取订单详情失败: %v", err)
-	}
-
-	return details, nil
+	details, failures := s.GetOrderDetails(ctx, orderNos)
+	return details, failures, nil
 }
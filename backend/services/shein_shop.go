@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+	"haodun_manage/backend/utils"
+)
+
+// SheinBaseURLFor 返回接口环境对应的默认地址
+func SheinBaseURLFor(environment string) string {
+	if environment == models.ShopEnvProd {
+		return SheinProdBaseURL
+	}
+	return SheinTestBaseURL
+}
+
+// sheinAllowedHosts 凭证可配置的接口域名，仅限 Shein 正式与测试环境，避免密钥随请求头发往其他地址
+var sheinAllowedHosts = map[string]bool{
+	hostOf(SheinProdBaseURL): true,
+	hostOf(SheinTestBaseURL): true,
+}
+
+func hostOf(rawURL string) string {
+	u, _ := url.Parse(rawURL)
+	return u.Hostname()
+}
+
+// ValidateSheinBaseURL 校验凭证中自定义的接口地址：必须为 https 且域名为 Shein 正式或测试环境
+func ValidateSheinBaseURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return errors.New("接口地址格式错误，应以 https:// 开头")
+	}
+	if !sheinAllowedHosts[strings.ToLower(u.Hostname())] || u.User != nil {
+		return fmt.Errorf("接口地址只能是 %s 或 %s", SheinProdBaseURL, SheinTestBaseURL)
+	}
+	return nil
+}
+
+// NewSheinServiceFromCredential 根据店铺凭证创建 Shein 服务实例，密钥在此解密
+func NewSheinServiceFromCredential(credential *models.ShopCredential) (*SheinService, error) {
+	baseURL := strings.TrimRight(strings.TrimSpace(credential.BaseURL), "/")
+	if baseURL == "" {
+		baseURL = SheinBaseURLFor(credential.Environment)
+	} else if err := ValidateSheinBaseURL(baseURL); err != nil {
+		return nil, fmt.Errorf("店铺 %s 的%v", credential.ShopCode, err)
+	}
+	secret, err := utils.DecryptSecret(credential.AppSecret)
+	if err != nil {
+		return nil, fmt.Errorf("店铺 %s 的密钥无法解密: %v", credential.ShopCode, err)
+	}
+	return NewSheinService(credential.ShopCode, credential.AppKey, secret, baseURL), nil
+}
+
+// ResolveSheinService 按店铺编号读取已启用的凭证并创建 Shein 服务实例
+func ResolveSheinService(shopCode string) (*SheinService, error) {
+	shopCode = strings.TrimSpace(shopCode)
+	if shopCode == "" {
+		return nil, errors.New("请指定店铺编号")
+	}
+	var credential models.ShopCredential
+	if err := database.DB.Where("shop_code = ?", shopCode).First(&credential).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("店铺 %s 未配置 Shein 凭证", shopCode)
+		}
+		return nil, err
+	}
+	if !credential.Enabled {
+		return nil, fmt.Errorf("店铺 %s 的 Shein 凭证已停用", shopCode)
+	}
+	return NewSheinServiceFromCredential(&credential)
+}
+
+// ImportEnvSheinCredential 将旧版本在环境变量中配置的店铺凭证（SHEIN_SHOP_CODE、SHEIN_APP_KEY、
+// SHEIN_APP_SECRET）导入店铺凭证表，沿用旧版本使用的测试环境地址；该店铺已有凭证时不做任何修改
+func ImportEnvSheinCredential() {
+	shopCode := strings.TrimSpace(os.Getenv("SHEIN_SHOP_CODE"))
+	appKey := strings.TrimSpace(os.Getenv("SHEIN_APP_KEY"))
+	appSecret := strings.TrimSpace(os.Getenv("SHEIN_APP_SECRET"))
+	if appKey == "" || appSecret == "" || appKey == "your_app_key_here" {
+		return
+	}
+	if shopCode == "" {
+		log.Printf("shein env credential not imported: SHEIN_SHOP_CODE is empty")
+		return
+	}
+
+	var count int64
+	if err := database.DB.Model(&models.ShopCredential{}).Where("shop_code = ?", shopCode).Count(&count).Error; err != nil {
+		log.Printf("shein env credential not imported: %v", err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	secret, err := utils.EncryptSecret(appSecret)
+	if err != nil {
+		log.Printf("shein env credential not imported: %v", err)
+		return
+	}
+	credential := models.ShopCredential{
+		ShopCode:    shopCode,
+		AppKey:      appKey,
+		AppSecret:   secret,
+		Environment: models.ShopEnvTest,
+		Enabled:     true,
+		Remark:      "由环境变量导入",
+	}
+	if err := database.DB.Create(&credential).Error; err != nil {
+		log.Printf("shein env credential not imported: %v", err)
+		return
+	}
+	log.Printf("imported shein credential for shop %q from environment variables", shopCode)
+}
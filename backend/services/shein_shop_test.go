@@ -0,0 +1,25 @@
+package services_test
+
+import (
+	"testing"
+
+	"haodun_manage/backend/services"
+)
+
+func TestValidateSheinBaseURL(t *testing.T) {
+	cases := map[string]bool{
+		services.SheinProdBaseURL:                    true,
+		services.SheinTestBaseURL:                    true,
+		"https://OPENAPI.sheincorp.com/":             true,
+		"http://openapi.sheincorp.com":               false,
+		"https://openapi.sheincorp.com.evil.example": false,
+		"https://user@openapi.sheincorp.com":         false,
+		"https://169.254.169.254/latest/meta-data":   false,
+		"openapi.sheincorp.com":                      false,
+	}
+	for rawURL, want := range cases {
+		if err := services.ValidateSheinBaseURL(rawURL); (err == nil) != want {
+			t.Errorf("ValidateSheinBaseURL(%q) = %v, want valid=%v", rawURL, err, want)
+		}
+	}
+}
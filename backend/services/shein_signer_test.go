@@ -0,0 +1,97 @@
+package services_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"haodun_manage/backend/services"
+	"haodun_manage/backend/services/sheinmock"
+)
+
+func TestSheinSignerKnownVector(t *testing.T) {
+	signer := services.SheinSigner{OpenKeyID: "test-key", SecretKey: "test-secret"}
+	// 按开放平台文档独立计算：randomKey + base64(hex(HmacSHA256("openKeyId&timestamp&path", secretKey+randomKey)))
+	want := "ab12cMzVhMzk3YzQwZmYxMGE5ZTFkNGJkNmM5MTJlZTA3MDUyNDY1YzYwMzFiZTQyNmUyMjAwNGUwNjIzMjYzNDdlNw=="
+	if got := signer.Sign(services.OrderListPath, 1700000000000, "ab12c"); got != want {
+		t.Fatalf("Sign() = %s, want %s", got, want)
+	}
+}
+
+func TestSheinSignerVerify(t *testing.T) {
+	signer := services.SheinSigner{OpenKeyID: "test-key", SecretKey: "test-secret"}
+	req, _ := http.NewRequest(http.MethodPost, "http://example.com"+services.OrderListPath, nil)
+	if err := signer.SignRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	if err := signer.Verify(req, time.Now()); err != nil {
+		t.Fatalf("Verify() of a freshly signed request: %v", err)
+	}
+	if err := signer.Verify(req, time.Now().Add(services.SheinSignatureTTL+time.Minute)); err == nil {
+		t.Fatal("Verify() accepted an expired timestamp")
+	}
+
+	other := services.SheinSigner{OpenKeyID: "test-key", SecretKey: "other-secret"}
+	if err := other.Verify(req, time.Now()); err == nil {
+		t.Fatal("Verify() accepted a signature made with another secret")
+	}
+}
+
+// postRaw 直接向模拟服务发送请求，便于构造错误的签名头
+func postRaw(t *testing.T, url string, header map[string]string) (int, int) {
+	t.Helper()
+	body, _ := json.Marshal(services.OrderListRequest{Page: 1, PageSize: 10})
+	req, _ := http.NewRequest(http.MethodPost, url+services.OrderListPath, bytes.NewReader(body))
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Code int `json:"code"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result.Code
+}
+
+func TestSheinMockRejectsBadSignature(t *testing.T) {
+	srv := sheinmock.New("test-key", "test-secret")
+	defer srv.Close()
+
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	cases := map[string]map[string]string{
+		"missing signature": {
+			services.SheinHeaderOpenKeyID: "test-key",
+			services.SheinHeaderTimestamp: timestamp,
+		},
+		"tampered signature": {
+			services.SheinHeaderOpenKeyID: "test-key",
+			services.SheinHeaderTimestamp: timestamp,
+			services.SheinHeaderSignature: "ab12c" + strings.Repeat("A", 88),
+		},
+	}
+	for name, header := range cases {
+		status, code := postRaw(t, srv.URL, header)
+		if status != http.StatusUnauthorized || code != sheinmock.CodeSignatureInvalid {
+			t.Errorf("%s: got HTTP %d code %d, want 401 code %d", name, status, code, sheinmock.CodeSignatureInvalid)
+		}
+	}
+
+	before := srv.Requests(services.OrderListPath)
+	wrong := services.NewSheinService("S1", "test-key", "wrong-secret", srv.URL)
+	_, err := wrong.GetAllOrders(context.Background(), "2024-01-01 00:00:00", "2024-01-02 00:00:00", "")
+	if err == nil || !strings.Contains(err.Error(), "签名校验失败") {
+		t.Fatalf("client with wrong secret: err = %v, want signature error", err)
+	}
+	if n := srv.Requests(services.OrderListPath) - before; n != 1 {
+		t.Fatalf("signature failures should not be retried, got %d requests", n)
+	}
+}
@@ -0,0 +1,203 @@
+// Package sheinmock 提供进程内的 Shein 开放平台模拟服务，
+// 用于在不访问 Shein 的情况下验证签名、分页与错误处理
+package sheinmock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	"haodun_manage/backend/services"
+)
+
+// 模拟服务返回的业务错误码
+const (
+	CodeSignatureInvalid = 10001 // 签名校验失败
+	CodeBadRequest       = 10002 // 请求参数错误
+	CodeOrderNotFound    = 20001 // 订单不存在
+)
+
+// failure 预设的失败响应
+type failure struct {
+	status  int
+	code    int
+	message string
+	times   int
+}
+
+// Server Shein 开放平台模拟服务。订单按 update_time（为空时按 create_time）
+// 落在请求的时间范围内返回，列表按加入顺序分页
+type Server struct {
+	*httptest.Server
+
+	signer services.SheinSigner
+
+	mu       sync.Mutex
+	orders   []services.OrderDetail
+	failures map[string][]*failure
+	requests map[string]int
+}
+
+// New 启动模拟服务，openKeyID、secretKey 为客户端需使用的凭证
+func New(openKeyID, secretKey string) *Server {
+	s := &Server{
+		signer:   services.SheinSigner{OpenKeyID: openKeyID, SecretKey: secretKey},
+		failures: make(map[string][]*failure),
+		requests: make(map[string]int),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(services.OrderListPath, s.handleOrderList)
+	mux.HandleFunc(services.OrderDetailPath, s.handleOrderDetail)
+	s.Server = httptest.NewServer(s.verify(mux))
+	return s
+}
+
+// Service 返回指向模拟服务的 SheinService
+func (s *Server) Service(shopCode string) *services.SheinService {
+	return services.NewSheinService(shopCode, s.signer.OpenKeyID, s.signer.SecretKey, s.URL)
+}
+
+// AddOrders 添加订单
+func (s *Server) AddOrders(orders ...services.OrderDetail) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders = append(s.orders, orders...)
+}
+
+// FailHTTP 使接口接下来的 times 次请求返回指定 HTTP 状态码
+func (s *Server) FailHTTP(path string, status, times int) {
+	s.addFailure(path, &failure{status: status, message: http.StatusText(status), times: times})
+}
+
+// FailCode 使接口接下来的 times 次请求返回 HTTP 200 与指定业务错误码
+func (s *Server) FailCode(path string, code int, message string, times int) {
+	s.addFailure(path, &failure{status: http.StatusOK, code: code, message: message, times: times})
+}
+
+// Requests 返回接口收到的请求次数（含签名校验失败的请求）
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) addFailure(path string, f *failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[path] = append(s.failures[path], f)
+}
+
+// nextFailure 取出接口的下一个预设失败响应
+func (s *Server) nextFailure(path string) *failure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queue := s.failures[path]
+	if len(queue) == 0 {
+		return nil
+	}
+	f := queue[0]
+	f.times--
+	if f.times <= 0 {
+		s.failures[path] = queue[1:]
+	}
+	return f
+}
+
+// verify 统计请求并校验方法与签名，之后应用预设的失败响应
+func (s *Server) verify(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.URL.Path]++
+		s.mu.Unlock()
+
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, jsonBody{"code": CodeBadRequest, "message": "请求方法错误"})
+			return
+		}
+		if err := s.signer.Verify(r, time.Now()); err != nil {
+			writeJSON(w, http.StatusUnauthorized, jsonBody{"code": CodeSignatureInvalid, "message": err.Error()})
+			return
+		}
+		if f := s.nextFailure(r.URL.Path); f != nil {
+			writeJSON(w, f.status, jsonBody{"code": f.code, "message": f.message})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleOrderList(w http.ResponseWriter, r *http.Request) {
+	var req services.OrderListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Page < 1 || req.PageSize < 1 {
+		writeJSON(w, http.StatusOK, jsonBody{"code": CodeBadRequest, "message": "分页参数错误"})
+		return
+	}
+	start, err1 := time.ParseInLocation(services.SheinTimeLayout, req.StartTime, time.Local)
+	end, err2 := time.ParseInLocation(services.SheinTimeLayout, req.EndTime, time.Local)
+	if err1 != nil || err2 != nil {
+		writeJSON(w, http.StatusOK, jsonBody{"code": CodeBadRequest, "message": "时间格式错误"})
+		return
+	}
+
+	s.mu.Lock()
+	var matched []services.OrderListItem
+	for _, order := range s.orders {
+		if req.Status != "" && strconv.Itoa(order.OrderStatus) != req.Status {
+			continue
+		}
+		value := order.UpdateTime
+		if value == "" {
+			value = order.CreateTime
+		}
+		t, err := time.ParseInLocation(services.SheinTimeLayout, value, time.Local)
+		if err != nil || t.Before(start) || t.After(end) {
+			continue
+		}
+		matched = append(matched, services.OrderListItem{OrderNo: order.OrderNo})
+	}
+	s.mu.Unlock()
+
+	from := (req.Page - 1) * req.PageSize
+	if from > len(matched) {
+		from = len(matched)
+	}
+	to := from + req.PageSize
+	if to > len(matched) {
+		to = len(matched)
+	}
+	writeJSON(w, http.StatusOK, jsonBody{
+		"code":    0,
+		"message": "OK",
+		"data":    jsonBody{"total": len(matched), "list": matched[from:to]},
+	})
+}
+
+func (s *Server) handleOrderDetail(w http.ResponseWriter, r *http.Request) {
+	var req services.OrderDetailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.OrderNo == "" {
+		writeJSON(w, http.StatusOK, jsonBody{"code": CodeBadRequest, "message": "订单号不能为空"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, order := range s.orders {
+		if order.OrderNo == req.OrderNo {
+			writeJSON(w, http.StatusOK, jsonBody{"code": 0, "message": "OK", "data": order})
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, jsonBody{"code": CodeOrderNotFound, "message": "订单不存在"})
+}
+
+// jsonBody 响应体
+type jsonBody map[string]interface{}
+
+func writeJSON(w http.ResponseWriter, status int, body jsonBody) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
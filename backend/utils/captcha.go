@@ -1,43 +1,321 @@
 package utils
 
 import (
+	"bytes"
 	"context"
-	"fmt"
-	"math/rand"
-	"haodun_manage/backend/database"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"math/big"
+	"strings"
 	"time"
+
+	"haodun_manage/backend/database"
 )
 
 const (
-	CaptchaLength = 4
-	CaptchaTTL    = 5 * time.Minute
+	CaptchaLength  = 4
+	CaptchaTTL     = 5 * time.Minute
+	CaptchaCharset = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+	// CaptchaRateLimit 每个IP每分钟最多获取的验证码数量
+	CaptchaRateLimit = 20
+
+	captchaKeyPrefix  = "captcha:"
+	captchaRatePrefix = "captcha:rate:"
+
+	captchaMaxLength = 8
+	captchaScale     = 4
+	captchaWidth     = 160
+	captchaHeight    = 60
 )
 
-func GenerateCaptcha() (string, string) {
-	// 生成4位随机数字验证码
-	rand.Seed(time.Now().UnixNano())
-	code := fmt.Sprintf("%04d", rand.Intn(10000))
-	
-	// 生成唯一ID
-	captchaID := fmt.Sprintf("captcha:%d", time.Now().UnixNano())
-	
-	// 存储到Redis，5分钟过期
+var ErrCaptchaRateLimited = errors.New("验证码获取过于频繁，请稍后再试")
+
+// CaptchaSettings 验证码参数，可通过系统参数调整
+type CaptchaSettings struct {
+	Length    int
+	Charset   string
+	TTL       time.Duration
+	RateLimit int
+}
+
+// LoadCaptchaSettings 从系统参数读取验证码配置，不合法的值回退为默认值
+func LoadCaptchaSettings() CaptchaSettings {
+	settings := CaptchaSettings{
+		Length:    GetConfigInt("captcha_length", CaptchaLength),
+		Charset:   strings.ToUpper(GetConfigString("captcha_charset", CaptchaCharset)),
+		TTL:       time.Duration(GetConfigInt("captcha_ttl_seconds", int(CaptchaTTL.Seconds()))) * time.Second,
+		RateLimit: GetConfigInt("captcha_rate_limit", CaptchaRateLimit),
+	}
+
+	if settings.Length <= 0 || settings.Length > captchaMaxLength {
+		settings.Length = CaptchaLength
+	}
+	settings.Charset = filterRenderableCharset(settings.Charset)
+	if len(settings.Charset) < 2 {
+		settings.Charset = CaptchaCharset
+	}
+	if settings.TTL <= 0 {
+		settings.TTL = CaptchaTTL
+	}
+	return settings
+}
+
+// AllowCaptchaRequest 按IP限制验证码获取频率
+func AllowCaptchaRequest(ip string, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
 	ctx := context.Background()
-	database.RedisClient.Set(ctx, captchaID, code, CaptchaTTL)
-	
-	return captchaID, code
+	key := captchaRatePrefix + ip
+	count, err := database.RedisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return true
+	}
+	if count == 1 {
+		database.RedisClient.Expire(ctx, key, time.Minute)
+	}
+	return count <= int64(limit)
+}
+
+// GenerateCaptcha 生成验证码并存入Redis，返回验证码ID与 Base64 编码的 PNG 图片
+func GenerateCaptcha(settings CaptchaSettings) (string, string, error) {
+	code, err := randomCaptchaCode(settings.Charset, settings.Length)
+	if err != nil {
+		return "", "", err
+	}
+
+	idBytes := make([]byte, 16)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", "", err
+	}
+	captchaID := captchaKeyPrefix + hex.EncodeToString(idBytes)
+
+	img, err := renderCaptcha(code)
+	if err != nil {
+		return "", "", err
+	}
+
+	ctx := context.Background()
+	if err := database.RedisClient.Set(ctx, captchaID, code, settings.TTL).Err(); err != nil {
+		return "", "", err
+	}
+
+	return captchaID, base64.StdEncoding.EncodeToString(img), nil
 }
 
 func VerifyCaptcha(captchaID, code string) bool {
+	if !strings.HasPrefix(captchaID, captchaKeyPrefix) || strings.HasPrefix(captchaID, captchaRatePrefix) {
+		return false
+	}
+
 	ctx := context.Background()
 	storedCode, err := database.RedisClient.Get(ctx, captchaID).Result()
 	if err != nil {
 		return false
 	}
-	
+
 	// 验证后删除
 	database.RedisClient.Del(ctx, captchaID)
-	
-	return storedCode == code
+
+	return strings.EqualFold(storedCode, strings.TrimSpace(code))
 }
 
+func randomCaptchaCode(charset string, length int) (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(charset)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(charset[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+func randomInt(max int) int {
+	if max <= 0 {
+		return 0
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0
+	}
+	return int(n.Int64())
+}
+
+// filterRenderableCharset 去掉字库中没有的字符及重复字符
+func filterRenderableCharset(charset string) string {
+	seen := make(map[rune]struct{})
+	var sb strings.Builder
+	for _, ch := range charset {
+		if _, ok := captchaGlyphs[ch]; !ok {
+			continue
+		}
+		if _, ok := seen[ch]; ok {
+			continue
+		}
+		seen[ch] = struct{}{}
+		sb.WriteRune(ch)
+	}
+	return sb.String()
+}
+
+// renderCaptcha 将验证码绘制为带扭曲和干扰的 PNG 图片
+func renderCaptcha(code string) ([]byte, error) {
+	canvas := image.NewRGBA(image.Rect(0, 0, captchaWidth, captchaHeight))
+	background := color.RGBA{R: uint8(225 + randomInt(30)), G: uint8(225 + randomInt(30)), B: uint8(225 + randomInt(30)), A: 255}
+	for y := 0; y < captchaHeight; y++ {
+		for x := 0; x < captchaWidth; x++ {
+			canvas.Set(x, y, background)
+		}
+	}
+
+	// 干扰点
+	for i := 0; i < captchaWidth*captchaHeight/12; i++ {
+		canvas.Set(randomInt(captchaWidth), randomInt(captchaHeight), randomDarkColor(160))
+	}
+
+	glyphWidth := 5 * captchaScale
+	glyphHeight := 7 * captchaScale
+	slot := captchaWidth / (len(code) + 1)
+	for i, ch := range code {
+		glyph := captchaGlyphs[ch]
+		fg := randomDarkColor(120)
+		shear := float64(randomInt(9)-4) / 10
+		originX := slot/2 + i*slot + randomInt(slot-glyphWidth/2+1)
+		originY := (captchaHeight-glyphHeight)/2 + randomInt(13) - 6
+
+		for row, line := range glyph {
+			for col, bit := range line {
+				if bit != '#' {
+					continue
+				}
+				for dy := 0; dy < captchaScale; dy++ {
+					for dx := 0; dx < captchaScale; dx++ {
+						py := row*captchaScale + dy
+						px := col*captchaScale + dx + int(shear*float64(glyphHeight/2-py))
+						canvas.Set(originX+px, originY+py, fg)
+					}
+				}
+			}
+		}
+	}
+
+	// 干扰线
+	for i := 0; i < 4; i++ {
+		drawLine(canvas,
+			randomInt(captchaWidth/4), randomInt(captchaHeight),
+			captchaWidth-randomInt(captchaWidth/4), randomInt(captchaHeight),
+			randomDarkColor(150))
+	}
+
+	distorted := waveDistort(canvas, background)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, distorted); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// waveDistort 对图片做正弦波扭曲
+func waveDistort(src *image.RGBA, background color.RGBA) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	amplitude := 2 + float64(randomInt(3))
+	period := 40 + float64(randomInt(30))
+	phase := float64(randomInt(628)) / 100
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			sx := x + int(amplitude*math.Sin(2*math.Pi*float64(y)/period+phase))
+			sy := y + int(amplitude*math.Cos(2*math.Pi*float64(x)/period+phase))
+			if image.Pt(sx, sy).In(bounds) {
+				dst.Set(x, y, src.At(sx, sy))
+			} else {
+				dst.Set(x, y, background)
+			}
+		}
+	}
+	return dst
+}
+
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx := int(math.Abs(float64(x1 - x0)))
+	dy := -int(math.Abs(float64(y1 - y0)))
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.Set(x0, y0, c)
+		img.Set(x0, y0+1, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func randomDarkColor(max int) color.RGBA {
+	return color.RGBA{R: uint8(randomInt(max)), G: uint8(randomInt(max)), B: uint8(randomInt(max)), A: 255}
+}
+
+// captchaGlyphs 5x7 点阵字库
+var captchaGlyphs = map[rune][7]string{
+	'0': {" ### ", "#   #", "#  ##", "# # #", "##  #", "#   #", " ### "},
+	'1': {"  #  ", " ##  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'2': {" ### ", "#   #", "    #", "   # ", "  #  ", " #   ", "#####"},
+	'3': {"#####", "   # ", "  #  ", "   # ", "    #", "#   #", " ### "},
+	'4': {"   # ", "  ## ", " # # ", "#  # ", "#####", "   # ", "   # "},
+	'5': {"#####", "#    ", "#### ", "    #", "    #", "#   #", " ### "},
+	'6': {"  ## ", " #   ", "#    ", "#### ", "#   #", "#   #", " ### "},
+	'7': {"#####", "    #", "   # ", "  #  ", " #   ", " #   ", " #   "},
+	'8': {" ### ", "#   #", "#   #", " ### ", "#   #", "#   #", " ### "},
+	'9': {" ### ", "#   #", "#   #", " ####", "    #", "   # ", " ##  "},
+	'A': {" ### ", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'B': {"#### ", "#   #", "#   #", "#### ", "#   #", "#   #", "#### "},
+	'C': {" ### ", "#   #", "#    ", "#    ", "#    ", "#   #", " ### "},
+	'D': {"#### ", "#   #", "#   #", "#   #", "#   #", "#   #", "#### "},
+	'E': {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#####"},
+	'F': {"#####", "#    ", "#    ", "#### ", "#    ", "#    ", "#    "},
+	'G': {" ### ", "#   #", "#    ", "# ###", "#   #", "#   #", " ####"},
+	'H': {"#   #", "#   #", "#   #", "#####", "#   #", "#   #", "#   #"},
+	'I': {" ### ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'J': {"  ###", "   # ", "   # ", "   # ", "   # ", "#  # ", " ##  "},
+	'K': {"#   #", "#  # ", "# #  ", "##   ", "# #  ", "#  # ", "#   #"},
+	'L': {"#    ", "#    ", "#    ", "#    ", "#    ", "#    ", "#####"},
+	'M': {"#   #", "## ##", "# # #", "# # #", "#   #", "#   #", "#   #"},
+	'N': {"#   #", "#   #", "##  #", "# # #", "#  ##", "#   #", "#   #"},
+	'O': {" ### ", "#   #", "#   #", "#   #", "#   #", "#   #", " ### "},
+	'P': {"#### ", "#   #", "#   #", "#### ", "#    ", "#    ", "#    "},
+	'Q': {" ### ", "#   #", "#   #", "#   #", "# # #", "#  # ", " ## #"},
+	'R': {"#### ", "#   #", "#   #", "#### ", "# #  ", "#  # ", "#   #"},
+	'S': {" ####", "#    ", "#    ", " ### ", "    #", "    #", "#### "},
+	'T': {"#####", "  #  ", "  #  ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'U': {"#   #", "#   #", "#   #", "#   #", "#   #", "#   #", " ### "},
+	'V': {"#   #", "#   #", "#   #", "#   #", "#   #", " # # ", "  #  "},
+	'W': {"#   #", "#   #", "#   #", "# # #", "# # #", "# # #", " # # "},
+	'X': {"#   #", "#   #", " # # ", "  #  ", " # # ", "#   #", "#   #"},
+	'Y': {"#   #", "#   #", " # # ", "  #  ", "  #  ", "  #  ", "  #  "},
+	'Z': {"#####", "    #", "   # ", "  #  ", " #   ", "#    ", "#####"},
+}
@@ -99,6 +99,19 @@ func UploadToCOS(ctx context.Context, objectKey string, reader io.Reader, conten
 	return BuildCOSObjectURL(objectKey)
 }
 
+// DownloadFromCOS opens object content for reading, caller must close it.
+func DownloadFromCOS(ctx context.Context, objectKey string) (io.ReadCloser, error) {
+	client, err := GetCOSClient()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Object.Get(ctx, objectKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
 // DeleteFromCOS removes object by key.
 func DeleteFromCOS(ctx context.Context, objectKey string) error {
 	client, err := GetCOSClient()
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	"haodun_manage/backend/config"
+)
+
+// credentialKeyMinLength CREDENTIAL_ENCRYPT_KEY 的最短长度
+const credentialKeyMinLength = 16
+
+// ErrCredentialKeyMissing 未配置专用的加密密钥，拒绝保存或读取凭证
+var ErrCredentialKeyMissing = errors.New("未配置 CREDENTIAL_ENCRYPT_KEY（至少16个字符），无法保存或读取店铺凭证")
+
+// CredentialKeyConfigured 是否配置了可用的凭证加密密钥
+func CredentialKeyConfigured() bool {
+	key := config.AppConfig.CredentialKey
+	return len(key) >= credentialKeyMinLength && key != config.AppConfig.JWTSecret
+}
+
+// credentialKey 由 CREDENTIAL_ENCRYPT_KEY 派生的 AES-256 密钥，不与 JWT_SECRET 共用
+func credentialKey() ([]byte, error) {
+	if !CredentialKeyConfigured() {
+		return nil, ErrCredentialKeyMissing
+	}
+	sum := sha256.Sum256([]byte(config.AppConfig.CredentialKey))
+	return sum[:], nil
+}
+
+// EncryptSecret 使用 AES-GCM 加密敏感配置，返回 base64 编码的 nonce+密文
+func EncryptSecret(plain string) (string, error) {
+	gcm, err := newCredentialGCM()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptSecret 解密 EncryptSecret 的结果
+func DecryptSecret(encrypted string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newCredentialGCM()
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("密文格式错误")
+	}
+	nonce, sealed := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", errors.New("解密失败，请确认加密密钥未被修改")
+	}
+	return string(plain), nil
+}
+
+func newCredentialGCM() (cipher.AEAD, error) {
+	key, err := credentialKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
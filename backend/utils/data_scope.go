@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+// DataScope 用户可见的业务数据范围
+type DataScope struct {
+	All        bool     // 不限制创建人
+	CreatorIDs []uint64 // All 为 false 时可见数据的创建人ID
+}
+
+// Allows 判断指定创建人的数据是否在可见范围内
+func (s *DataScope) Allows(createdBy uint64) bool {
+	if s.All {
+		return true
+	}
+	for _, id := range s.CreatorIDs {
+		if id == createdBy {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveDataScope 根据用户所属角色的数据范围与所在部门，解析其可见数据的创建人集合
+func ResolveDataScope(userID uint) (*DataScope, error) {
+	self := &DataScope{CreatorIDs: []uint64{uint64(userID)}}
+
+	var user models.User
+	if err := database.DB.Preload("Role").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	var departmentIDs []uint
+	switch models.NormalizeDataScope(user.Role.DataScope) {
+	case models.DataScopeAll:
+		return &DataScope{All: true}, nil
+	case models.DataScopeDept:
+		if user.DepartmentID == 0 {
+			return self, nil
+		}
+		departmentIDs = []uint{user.DepartmentID}
+	case models.DataScopeDeptTree:
+		if user.DepartmentID == 0 {
+			return self, nil
+		}
+		ids, err := DepartmentTreeIDs(user.DepartmentID)
+		if err != nil {
+			return nil, err
+		}
+		departmentIDs = ids
+	default:
+		return self, nil
+	}
+
+	var memberIDs []uint64
+	if err := database.DB.Model(&models.User{}).
+		Where("department_id IN ?", departmentIDs).
+		Pluck("id", &memberIDs).Error; err != nil {
+		return nil, err
+	}
+
+	scope := &DataScope{CreatorIDs: memberIDs}
+	if !scope.Allows(uint64(userID)) {
+		scope.CreatorIDs = append(scope.CreatorIDs, uint64(userID))
+	}
+	return scope, nil
+}
+
+// DepartmentTreeIDs 返回部门自身及其全部下级部门的ID（通过 ParentID 逐层展开）
+func DepartmentTreeIDs(rootID uint) ([]uint, error) {
+	var departments []models.Department
+	if err := database.DB.Select("id", "parent_id").Find(&departments).Error; err != nil {
+		return nil, err
+	}
+
+	children := make(map[uint][]uint, len(departments))
+	for _, dept := range departments {
+		if dept.ParentID != nil {
+			children[*dept.ParentID] = append(children[*dept.ParentID], dept.ID)
+		}
+	}
+
+	ids := []uint{rootID}
+	visited := map[uint]bool{rootID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+			ids = append(ids, child)
+		}
+	}
+	return ids, nil
+}
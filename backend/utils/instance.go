@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// InstanceID 当前服务进程的标识（主机名-进程号-随机串），用于区分多实例部署时后台任务的归属
+var InstanceID = newInstanceID()
+
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	id := fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(buf))
+	if len(id) > 64 {
+		id = id[len(id)-64:]
+	}
+	return id
+}
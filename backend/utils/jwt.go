@@ -1,27 +1,45 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"haodun_manage/backend/config"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// AccessTokenTTL 访问令牌有效期，过期后通过刷新令牌换取新的访问令牌
+const AccessTokenTTL = 15 * time.Minute
+
 type Claims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
 	RoleID   uint   `json:"role_id"`
+	FamilyID string `json:"fid,omitempty"` // 所属刷新令牌家族（一次登录）
+	// MustChangePassword 签发时必须修改密码，此时仅允许调用修改密码与注销接口
+	MustChangePassword bool `json:"mcp,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID uint, username string, roleID uint) (string, error) {
+func GenerateToken(userID uint, username string, roleID uint, familyID string, mustChangePassword bool) (string, error) {
+	tokenID, err := generateTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		RoleID:   roleID,
+		UserID:             userID,
+		Username:           username,
+		RoleID:             roleID,
+		FamilyID:           familyID,
+		MustChangePassword: mustChangePassword,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        tokenID,
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
@@ -32,7 +50,7 @@ func GenerateToken(userID uint, username string, roleID uint) (string, error) {
 func ParseToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(config.AppConfig.JWTSecret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -42,6 +60,13 @@ func ParseToken(tokenString string) (*Claims, error) {
 		return claims, nil
 	}
 
-	return nil, err
+	return nil, fmt.Errorf("invalid token")
 }
 
+func generateTokenID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"haodun_manage/backend/database"
+)
+
+const lockKeyPrefix = "lock:"
+
+// 仅在锁仍由当前持有者持有时释放或续期，避免误删其他实例的锁
+var (
+	releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+	extendLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+// DistributedLock 基于 Redis 的分布式锁
+type DistributedLock struct {
+	key   string
+	token string
+}
+
+// TryLock 尝试获取锁，已被其他实例持有时返回 nil
+func TryLock(ctx context.Context, name string, ttl time.Duration) (*DistributedLock, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	lock := &DistributedLock{key: lockKeyPrefix + name, token: hex.EncodeToString(buf)}
+	ok, err := database.RedisClient.SetNX(ctx, lock.key, lock.token, ttl).Result()
+	if err != nil || !ok {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// Extend 续期锁，返回 false 表示锁已过期或被其他实例持有
+func (l *DistributedLock) Extend(ctx context.Context, ttl time.Duration) (bool, error) {
+	result, err := extendLockScript.Run(ctx, database.RedisClient, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+	return result == 1, err
+}
+
+// Release 释放锁
+func (l *DistributedLock) Release(ctx context.Context) error {
+	return releaseLockScript.Run(ctx, database.RedisClient, []string{l.key}, l.token).Err()
+}
+
+// IsLocked 判断锁当前是否被持有
+func IsLocked(ctx context.Context, name string) (bool, error) {
+	count, err := database.RedisClient.Exists(ctx, lockKeyPrefix+name).Result()
+	return count > 0, err
+}
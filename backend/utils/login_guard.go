@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"haodun_manage/backend/database"
+)
+
+const (
+	// LoginCaptchaThreshold 用户名或IP失败次数达到该值后必须提交验证码
+	LoginCaptchaThreshold = 3
+	// LoginUserLockThreshold 同一用户名失败次数达到该值后临时锁定账号
+	LoginUserLockThreshold = 5
+	// LoginIPLockThreshold 同一IP失败次数达到该值后临时禁止该IP登录
+	LoginIPLockThreshold = 20
+
+	loginFailureWindow = 15 * time.Minute
+	loginLockDuration  = 15 * time.Minute
+	loginMaxDelay      = 5 * time.Second
+
+	loginFailUserPrefix = "login:fail:user:"
+	loginFailIPPrefix   = "login:fail:ip:"
+	loginLockUserPrefix = "login:lock:user:"
+	loginLockIPPrefix   = "login:lock:ip:"
+)
+
+func normalizeLoginUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// LoginLockRemaining 返回用户名或IP的剩余锁定时间，未锁定时返回0
+func LoginLockRemaining(username, ip string) time.Duration {
+	ctx := context.Background()
+	var remaining time.Duration
+	for _, key := range []string{
+		loginLockUserPrefix + normalizeLoginUsername(username),
+		loginLockIPPrefix + ip,
+	} {
+		if ttl, err := database.RedisClient.TTL(ctx, key).Result(); err == nil && ttl > remaining {
+			remaining = ttl
+		}
+	}
+	return remaining
+}
+
+// LoginFailureCount 返回用户名与IP失败次数中的较大值
+func LoginFailureCount(username, ip string) int {
+	ctx := context.Background()
+	userCount, _ := database.RedisClient.Get(ctx, loginFailUserPrefix+normalizeLoginUsername(username)).Int()
+	ipCount, _ := database.RedisClient.Get(ctx, loginFailIPPrefix+ip).Int()
+	if ipCount > userCount {
+		return ipCount
+	}
+	return userCount
+}
+
+// LoginCaptchaRequired 判断本次登录是否必须提交验证码
+func LoginCaptchaRequired(username, ip string) bool {
+	return LoginFailureCount(username, ip) >= LoginCaptchaThreshold
+}
+
+// RecordLoginFailure 累加失败次数，达到阈值时加锁，返回累加后的失败次数
+func RecordLoginFailure(username, ip string) int {
+	ctx := context.Background()
+	userKey := loginFailUserPrefix + normalizeLoginUsername(username)
+	ipKey := loginFailIPPrefix + ip
+
+	pipe := database.RedisClient.TxPipeline()
+	userIncr := pipe.Incr(ctx, userKey)
+	pipe.Expire(ctx, userKey, loginFailureWindow)
+	ipIncr := pipe.Incr(ctx, ipKey)
+	pipe.Expire(ctx, ipKey, loginFailureWindow)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0
+	}
+
+	userCount := int(userIncr.Val())
+	ipCount := int(ipIncr.Val())
+	if userCount >= LoginUserLockThreshold {
+		database.RedisClient.Set(ctx, loginLockUserPrefix+normalizeLoginUsername(username), 1, loginLockDuration)
+	}
+	if ipCount >= LoginIPLockThreshold {
+		database.RedisClient.Set(ctx, loginLockIPPrefix+ip, 1, loginLockDuration)
+	}
+
+	if ipCount > userCount {
+		return ipCount
+	}
+	return userCount
+}
+
+// LoginFailureDelay 按失败次数计算递增的响应延迟
+func LoginFailureDelay(failures int) time.Duration {
+	if failures <= 1 {
+		return 0
+	}
+	delay := time.Duration(1<<uint(failures-2)) * 250 * time.Millisecond
+	if delay > loginMaxDelay || delay <= 0 {
+		return loginMaxDelay
+	}
+	return delay
+}
+
+// ClearLoginFailures 登录成功后清除用户名的失败计数
+func ClearLoginFailures(username string) {
+	database.RedisClient.Del(context.Background(), loginFailUserPrefix+normalizeLoginUsername(username))
+}
+
+// UnlockLogin 管理员解除用户名锁定，并清除其失败计数
+func UnlockLogin(username string) error {
+	name := normalizeLoginUsername(username)
+	return database.RedisClient.Del(context.Background(), loginLockUserPrefix+name, loginFailUserPrefix+name).Err()
+}
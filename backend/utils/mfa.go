@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"haodun_manage/backend/database"
+)
+
+const (
+	// MFAChallengeTTL 登录二次验证挑战的有效期
+	MFAChallengeTTL = 5 * time.Minute
+	// MFAEnrollTTL 待确认的 TOTP 密钥有效期
+	MFAEnrollTTL = 10 * time.Minute
+
+	mfaChallengeMaxAttempts = 5
+
+	mfaChallengePrefix = "mfa:challenge:"
+	mfaAttemptsPrefix  = "mfa:attempts:"
+	mfaEnrollPrefix    = "mfa:enroll:"
+	mfaTOTPStepPrefix  = "mfa:totp-step:"
+
+	// mfaTOTPStepTTL 覆盖口令的有效窗口即可，过期后旧口令本身已无法通过校验
+	mfaTOTPStepTTL = (2*totpSkew + 2) * totpPeriod * time.Second
+)
+
+// acceptTOTPStepScript 仅当时间步大于上次接受的时间步时记录并返回 1
+var acceptTOTPStepScript = redis.NewScript(`
+local last = tonumber(redis.call("GET", KEYS[1]) or "-1")
+if tonumber(ARGV[1]) <= last then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+var ErrMFAChallengeInvalid = errors.New("二次验证已过期，请重新登录")
+
+// CreateMFAChallenge 密码校验通过后创建待完成的二次验证挑战，返回挑战令牌
+func CreateMFAChallenge(userID uint) (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	err := database.RedisClient.Set(context.Background(), mfaChallengePrefix+token, userID, MFAChallengeTTL).Err()
+	return token, err
+}
+
+// GetMFAChallengeUser 返回挑战对应的用户ID
+func GetMFAChallengeUser(token string) (uint, error) {
+	if token == "" {
+		return 0, ErrMFAChallengeInvalid
+	}
+	value, err := database.RedisClient.Get(context.Background(), mfaChallengePrefix+token).Result()
+	if err != nil {
+		return 0, ErrMFAChallengeInvalid
+	}
+	userID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, ErrMFAChallengeInvalid
+	}
+	return uint(userID), nil
+}
+
+// RecordMFAChallengeFailure 记录一次口令错误，超过次数后挑战作废
+func RecordMFAChallengeFailure(token string) {
+	ctx := context.Background()
+	key := mfaAttemptsPrefix + token
+	attempts, err := database.RedisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return
+	}
+	database.RedisClient.Expire(ctx, key, MFAChallengeTTL)
+	if attempts >= mfaChallengeMaxAttempts {
+		ConsumeMFAChallenge(token)
+	}
+}
+
+// ConsumeMFAChallenge 删除挑战，保证一次性使用
+func ConsumeMFAChallenge(token string) {
+	database.RedisClient.Del(context.Background(), mfaChallengePrefix+token, mfaAttemptsPrefix+token)
+}
+
+// SavePendingTOTPSecret 暂存尚未确认的 TOTP 密钥
+func SavePendingTOTPSecret(userID uint, secret string) error {
+	key := mfaEnrollPrefix + strconv.FormatUint(uint64(userID), 10)
+	return database.RedisClient.Set(context.Background(), key, secret, MFAEnrollTTL).Err()
+}
+
+// GetPendingTOTPSecret 读取尚未确认的 TOTP 密钥
+func GetPendingTOTPSecret(userID uint) string {
+	key := mfaEnrollPrefix + strconv.FormatUint(uint64(userID), 10)
+	secret, _ := database.RedisClient.Get(context.Background(), key).Result()
+	return secret
+}
+
+// ClearPendingTOTPSecret 删除暂存的 TOTP 密钥
+func ClearPendingTOTPSecret(userID uint) {
+	key := mfaEnrollPrefix + strconv.FormatUint(uint64(userID), 10)
+	database.RedisClient.Del(context.Background(), key)
+}
+
+// AcceptTOTPStep 记录用户本次使用的口令时间步；同一时间步或更早的口令已使用过时返回 false
+func AcceptTOTPStep(userID uint, step int64) bool {
+	key := mfaTOTPStepPrefix + strconv.FormatUint(uint64(userID), 10)
+	accepted, err := acceptTOTPStepScript.Run(context.Background(), database.RedisClient,
+		[]string{key}, step, mfaTOTPStepTTL.Milliseconds()).Int()
+	return err == nil && accepted == 1
+}
@@ -0,0 +1,148 @@
+package utils
+
+import (
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publicRoutes 无需登录的接口，不经过权限校验
+var publicRoutes = []string{
+	"POST /api/auth/login",
+	"POST /api/auth/refresh",
+	"POST /api/auth/mfa/setup",
+	"POST /api/auth/mfa/verify",
+	"GET /api/auth/captcha",
+	"GET /api/dict/:code",
+	"GET /api/config/:key",
+}
+
+// openRoutes 未登记为资源时对所有登录用户开放的接口：个人账号操作、只读查询，
+// 以及按数据范围限制的订单、素材接口。其余未登记的接口一律拒绝非超级管理员访问，
+// 新增接口须登记为资源或加入此列表
+var openRoutes = []string{
+	// 个人账号
+	"GET /api/auth/current-user",
+	"POST /api/auth/logout",
+	"GET /api/auth/sessions",
+	"DELETE /api/auth/sessions/:id",
+	"POST /api/auth/mfa/enroll",
+	"POST /api/auth/mfa/enable",
+	"POST /api/auth/mfa/disable",
+	"PUT /api/auth/change-password",
+
+	// 系统管理查询
+	"GET /api/users",
+	"GET /api/users/:id",
+	"GET /api/roles",
+	"GET /api/roles/:id",
+	"GET /api/permissions",
+	"GET /api/permissions/:id",
+	"GET /api/resources",
+	"GET /api/resources/:id",
+	"GET /api/logs",
+	"GET /api/logs/options",
+	"GET /api/logs/:id",
+	"GET /api/ip-accesses",
+	"GET /api/ip-statistics",
+	"GET /api/dict-types",
+	"GET /api/dict-types/:id",
+	"GET /api/dict-items",
+	"GET /api/dict-items/:id",
+	"GET /api/configs",
+	"GET /api/configs/groups",
+	"GET /api/configs/:id",
+	"GET /api/departments",
+	"GET /api/departments/:id",
+	"GET /api/system/metrics",
+	"GET /api/storage/settings",
+
+	// 消息公告
+	"GET /api/notices",
+	"GET /api/notices/:id",
+	"PUT /api/notices/:id/read",
+
+	// 订单及附件
+	"GET /api/orders",
+	"POST /api/orders",
+	"PUT /api/orders/:id",
+	"DELETE /api/orders/:id",
+	"GET /api/orders/:id/status-history",
+	"POST /api/orders/shipping-labels/merge",
+	"GET /api/orders/:id/attachments",
+	"POST /api/orders/:id/attachments",
+	"POST /api/orders/:id/attachments/link",
+	"POST /api/orders/batch-attachments",
+	"GET /api/orders/:id/attachments/:attachmentId/download",
+	"DELETE /api/orders/:id/attachments/:attachmentId",
+
+	// 订单导入导出
+	"POST /api/orders/import",
+	"GET /api/import-jobs/:id",
+	"GET /api/import-jobs/:id/error-report",
+	"GET /api/import-profiles",
+	"GET /api/import-profiles/:id",
+	"GET /api/orders/export",
+	"GET /api/orders/export/columns",
+	"GET /api/orders/export/bundle",
+
+	// 素材图库
+	"GET /api/material-folders",
+	"POST /api/material-folders",
+	"PUT /api/material-folders/:id",
+	"DELETE /api/material-folders/:id",
+	"GET /api/materials",
+	"GET /api/materials/:id",
+	"POST /api/materials",
+	"PUT /api/materials/:id",
+	"DELETE /api/materials/:id",
+	"POST /api/materials/upload",
+	"GET /api/materials/:id/download",
+}
+
+var (
+	publicRouteKeys = routeKeySet(publicRoutes)
+	openRouteKeys   = routeKeySet(openRoutes)
+)
+
+func routeKeySet(routes []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(routes))
+	for _, route := range routes {
+		method, path, _ := strings.Cut(route, " ")
+		set[ResourceKey(method, path)] = struct{}{}
+	}
+	return set
+}
+
+// IsOpenRoute 接口是否在开放列表中
+func IsOpenRoute(method, path string) bool {
+	_, ok := openRouteKeys[ResourceKey(method, path)]
+	return ok
+}
+
+// CheckRouteResources 启动时检查接口登记情况：既未登记为资源、也不在公开或开放列表中的接口
+// 只有超级管理员可以访问，逐条记录警告以便补充登记
+func CheckRouteResources(routes gin.RoutesInfo) {
+	protected, err := GetProtectedResourceKeys()
+	if err != nil {
+		log.Printf("failed to check route resources: %v", err)
+		return
+	}
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/api/") {
+			continue
+		}
+		key := ResourceKey(route.Method, route.Path)
+		if _, ok := protected[key]; ok {
+			continue
+		}
+		if _, ok := publicRouteKeys[key]; ok {
+			continue
+		}
+		if _, ok := openRouteKeys[key]; ok {
+			continue
+		}
+		log.Printf("警告: 接口 %s 未登记为资源且不在开放列表中，仅超级管理员可访问", key)
+	}
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"sync"
+
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -14,6 +16,20 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// CheckDummyPassword 与固定的哈希比对密码并丢弃结果，用户不存在时调用，
+// 使其耗时与真实的密码校验一致，避免通过响应时间探测用户名是否存在
+func CheckDummyPassword(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("haodun_manage:dummy-password"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
+
 // IsBcryptHash 检查字符串是否是 bcrypt 哈希格式
 func IsBcryptHash(s string) bool {
 	return len(s) == 60 && (s[:4] == "$2a$" || s[:4] == "$2b$" || s[:4] == "$2y$")
@@ -0,0 +1,128 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+// PasswordPolicy 密码策略，由系统参数配置
+type PasswordPolicy struct {
+	MinLength        int
+	MinCharClasses   int
+	DisallowUsername bool
+	HistoryCount     int
+	MaxAgeDays       int
+}
+
+// LoadPasswordPolicy 从系统参数读取密码策略
+func LoadPasswordPolicy() PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:        GetConfigInt("password_min_length", 8),
+		MinCharClasses:   GetConfigInt("password_min_char_classes", 3),
+		DisallowUsername: GetConfigInt("password_disallow_username", 1) == 1,
+		HistoryCount:     GetConfigInt("password_history_count", 5),
+		MaxAgeDays:       GetConfigInt("password_max_age_days", 90),
+	}
+	if policy.MinLength < 1 {
+		policy.MinLength = 1
+	}
+	if policy.MinCharClasses > 4 {
+		policy.MinCharClasses = 4
+	}
+	return policy
+}
+
+// Validate 校验密码强度
+func (p PasswordPolicy) Validate(password, username string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("密码长度不能少于%d位", p.MinLength)
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, ch := range password {
+		switch {
+		case unicode.IsLower(ch):
+			hasLower = true
+		case unicode.IsUpper(ch):
+			hasUpper = true
+		case unicode.IsDigit(ch):
+			hasDigit = true
+		case unicode.IsSpace(ch):
+			return fmt.Errorf("密码不能包含空白字符")
+		default:
+			hasSymbol = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{hasLower, hasUpper, hasDigit, hasSymbol} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < p.MinCharClasses {
+		return fmt.Errorf("密码需至少包含小写字母、大写字母、数字、特殊字符中的%d类", p.MinCharClasses)
+	}
+
+	name := strings.ToLower(strings.TrimSpace(username))
+	if p.DisallowUsername && name != "" && strings.Contains(strings.ToLower(password), name) {
+		return fmt.Errorf("密码不能包含用户名")
+	}
+	return nil
+}
+
+// IsExpired 判断密码是否超过最长使用期限
+func (p PasswordPolicy) IsExpired(changedAt *time.Time) bool {
+	if p.MaxAgeDays <= 0 || changedAt == nil {
+		return false
+	}
+	return time.Since(*changedAt) > time.Duration(p.MaxAgeDays)*24*time.Hour
+}
+
+// PasswordChangeRequired 用户是否必须先修改密码：管理员重置过密码或密码已过期
+func PasswordChangeRequired(user *models.User) bool {
+	return user.MustChangePassword || LoadPasswordPolicy().IsExpired(user.PasswordChangedAt)
+}
+
+// IsPasswordReused 检查新密码是否与最近N次使用过的密码相同
+func (p PasswordPolicy) IsPasswordReused(userID uint, password string) bool {
+	if p.HistoryCount <= 0 || userID == 0 {
+		return false
+	}
+	var histories []models.PasswordHistory
+	database.DB.Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(p.HistoryCount).
+		Find(&histories)
+	for _, history := range histories {
+		if CheckPasswordHash(password, history.PasswordHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordPasswordHistory 记录新密码哈希，并清理超出保留数量的旧记录
+func RecordPasswordHistory(userID uint, hash string, keep int) error {
+	if err := database.DB.Create(&models.PasswordHistory{UserID: userID, PasswordHash: hash}).Error; err != nil {
+		return err
+	}
+	if keep <= 0 {
+		keep = 1
+	}
+	var staleIDs []uint64
+	database.DB.Model(&models.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Offset(keep).
+		Limit(1000).
+		Pluck("id", &staleIDs)
+	if len(staleIDs) > 0 {
+		return database.DB.Where("id IN ?", staleIDs).Delete(&models.PasswordHistory{}).Error
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+const (
+	pdfPageWidth    = 595 // A4，单位 pt
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfLineHeight   = 18
+	pdfFontSize     = 11
+	pdfTitleSize    = 18
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin - pdfLineHeight*2) / pdfLineHeight
+)
+
+func init() {
+	// 不读取也不生成 pdfcpu 的本地配置文件
+	model.ConfigPath = "disable"
+}
+
+// BuildTextPDF 生成纯文本 PDF，title 显示在首页顶部，lines 按页自动分页。
+// 使用 PDF 阅读器内置的 STSong-Light 中文字体，无需嵌入字体文件
+func BuildTextPDF(title string, lines []string) []byte {
+	var pages [][]string
+	for start := 0; start == 0 || start < len(lines); start += pdfLinesPerPage {
+		end := start + pdfLinesPerPage
+		if end > len(lines) {
+			end = len(lines)
+		}
+		pages = append(pages, lines[start:end])
+	}
+
+	// 对象编号：1 目录，2 页面树，3-5 字体，之后每页依次为页面与内容流
+	const fontObject = 3
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // 页面树，待页面编号确定后填写
+		"<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H /DescendantFonts [4 0 R] >>",
+		"<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light " +
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 4 >> /FontDescriptor 5 0 R /DW 1000 /W [1 95 500] >>",
+		"<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+			"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>",
+	}
+
+	kids := make([]string, 0, len(pages))
+	for i, pageLines := range pages {
+		var content strings.Builder
+		y := pdfPageHeight - pdfMargin
+		if i == 0 && title != "" {
+			fmt.Fprintf(&content, "BT /F1 %d Tf %d %d Td <%s> Tj ET\n", pdfTitleSize, pdfMargin, y-pdfTitleSize, pdfHexText(title))
+			y -= pdfLineHeight * 2
+		}
+		for _, line := range pageLines {
+			y -= pdfLineHeight
+			fmt.Fprintf(&content, "BT /F1 %d Tf %d %d Td <%s> Tj ET\n", pdfFontSize, pdfMargin, y, pdfHexText(line))
+		}
+		fmt.Fprintf(&content, "BT /F1 9 Tf %d %d Td <%s> Tj ET\n", pdfPageWidth-pdfMargin-60, pdfMargin/2,
+			pdfHexText(fmt.Sprintf("%d / %d", i+1, len(pages))))
+
+		pageObject := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObject))
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, fontObject, pageObject+1),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+		)
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfHexText 将文本编码为 UCS-2 大端十六进制串，超出基本平面的字符以问号代替
+func pdfHexText(text string) string {
+	var builder strings.Builder
+	for _, r := range text {
+		if r > 0xFFFF || utf16.IsSurrogate(r) {
+			r = '?'
+		}
+		fmt.Fprintf(&builder, "%04X", r)
+	}
+	return builder.String()
+}
+
+// MergePDFs 按顺序合并多个 PDF 并写入 w
+func MergePDFs(files []io.ReadSeeker, w io.Writer) error {
+	if len(files) == 0 {
+		return fmt.Errorf("no pdf to merge")
+	}
+	return api.MergeRaw(files, w, false, model.NewDefaultConfiguration())
+}
+
+// ValidatePDF 校验 PDF 能否被正常解析
+func ValidatePDF(rs io.ReadSeeker) error {
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationRelaxed
+	return api.Validate(rs, conf)
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+const (
+	rolePermissionCachePrefix = "rbac:role:"
+	protectedResourceCacheKey = "rbac:resources"
+	rolePermissionCacheTTL    = 10 * time.Minute
+)
+
+// ResourceKey 生成资源的匹配键，格式为 "METHOD path"
+func ResourceKey(method, path string) string {
+	return strings.ToUpper(strings.TrimSpace(method)) + " " + strings.TrimSpace(path)
+}
+
+func rolePermissionCacheKey(roleID uint) string {
+	return fmt.Sprintf("%s%d", rolePermissionCachePrefix, roleID)
+}
+
+func roleSuperAdminCacheKey(roleID uint) string {
+	return fmt.Sprintf("%s%d:super", rolePermissionCachePrefix, roleID)
+}
+
+// IsSuperAdminRole 判断角色是否为超级管理员，结果与角色权限一同缓存
+func IsSuperAdminRole(roleID uint) (bool, error) {
+	if roleID == 0 {
+		return false, nil
+	}
+	ctx := context.Background()
+	cacheKey := roleSuperAdminCacheKey(roleID)
+	if database.RedisClient != nil {
+		if raw, err := database.RedisClient.Get(ctx, cacheKey).Result(); err == nil {
+			return raw == "1", nil
+		}
+	}
+
+	var role models.Role
+	err := database.DB.Select("id", "is_super_admin").First(&role, roleID).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	if database.RedisClient != nil {
+		value := "0"
+		if role.IsSuperAdmin {
+			value = "1"
+		}
+		database.RedisClient.Set(ctx, cacheKey, value, rolePermissionCacheTTL)
+	}
+	return role.IsSuperAdmin, nil
+}
+
+// GetProtectedResourceKeys 返回所有已登记的资源键（登记过的接口需角色获授权才能访问）
+func GetProtectedResourceKeys() (map[string]struct{}, error) {
+	return loadKeySet(protectedResourceCacheKey, func() ([]string, error) {
+		var resources []models.Resource
+		if err := database.DB.Select("path", "method").Find(&resources).Error; err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(resources))
+		for _, resource := range resources {
+			keys = append(keys, ResourceKey(resource.Method, resource.Path))
+		}
+		return keys, nil
+	})
+}
+
+// GetRoleResourceKeys 通过 role_permissions 关联解析角色可访问的资源键
+func GetRoleResourceKeys(roleID uint) (map[string]struct{}, error) {
+	return loadKeySet(rolePermissionCacheKey(roleID), func() ([]string, error) {
+		type row struct {
+			Path   string
+			Method string
+		}
+		var rows []row
+		err := database.DB.Table("role_permissions").
+			Select("resources.path, resources.method").
+			Joins("JOIN permissions ON role_permissions.permission_id = permissions.id AND permissions.deleted_at IS NULL").
+			Joins("JOIN resources ON permissions.resource_id = resources.id AND resources.deleted_at IS NULL").
+			Where("role_permissions.role_id = ?", roleID).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(rows))
+		for _, r := range rows {
+			keys = append(keys, ResourceKey(r.Method, r.Path))
+		}
+		return keys, nil
+	})
+}
+
+// RoleCanAccessResource 判断角色能否调用指定接口：已登记为资源的接口需角色获授权，
+// 未登记的接口仅在开放列表中时允许访问，其余一律拒绝
+func RoleCanAccessResource(roleID uint, method, path string) (bool, error) {
+	key := ResourceKey(method, path)
+	protected, err := GetProtectedResourceKeys()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := protected[key]; !ok {
+		return IsOpenRoute(method, path), nil
+	}
+	granted, err := GetRoleResourceKeys(roleID)
+	if err != nil {
+		return false, err
+	}
+	_, ok := granted[key]
+	return ok, nil
+}
+
+// InvalidateRolePermissionCache 清除指定角色的权限缓存
+func InvalidateRolePermissionCache(roleIDs ...uint) {
+	if database.RedisClient == nil || len(roleIDs) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(roleIDs)*2)
+	for _, roleID := range roleIDs {
+		keys = append(keys, rolePermissionCacheKey(roleID), roleSuperAdminCacheKey(roleID))
+	}
+	database.RedisClient.Del(context.Background(), keys...)
+}
+
+// InvalidateAllPermissionCache 清除所有角色权限缓存及资源登记缓存，资源或权限定义变化时调用
+func InvalidateAllPermissionCache() {
+	if database.RedisClient == nil {
+		return
+	}
+	ctx := context.Background()
+	keys := []string{protectedResourceCacheKey}
+	iter := database.RedisClient.Scan(ctx, 0, rolePermissionCachePrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	database.RedisClient.Del(ctx, keys...)
+}
+
+// loadKeySet 优先读取 Redis 缓存，未命中时回源并写回缓存；Redis 不可用时直接回源
+func loadKeySet(cacheKey string, load func() ([]string, error)) (map[string]struct{}, error) {
+	ctx := context.Background()
+	var keys []string
+
+	cached := false
+	if database.RedisClient != nil {
+		if raw, err := database.RedisClient.Get(ctx, cacheKey).Result(); err == nil {
+			if json.Unmarshal([]byte(raw), &keys) == nil {
+				cached = true
+			}
+		}
+	}
+
+	if !cached {
+		loaded, err := load()
+		if err != nil {
+			return nil, err
+		}
+		keys = loaded
+		if database.RedisClient != nil {
+			if data, err := json.Marshal(keys); err == nil {
+				database.RedisClient.Set(ctx, cacheKey, data, rolePermissionCacheTTL)
+			}
+		}
+	}
+
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+	return set, nil
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+// RefreshTokenTTL 刷新令牌有效期，覆盖一个完整班次
+const RefreshTokenTTL = 12 * time.Hour
+
+var (
+	ErrRefreshTokenInvalid  = errors.New("刷新令牌无效")
+	ErrRefreshTokenExpired  = errors.New("刷新令牌已过期")
+	ErrRefreshTokenReused   = errors.New("刷新令牌已被使用，当前登录已失效")
+	ErrRefreshTokenMismatch = errors.New("刷新令牌与当前设备不匹配")
+)
+
+// NewTokenFamilyID 为一次登录生成令牌家族ID
+func NewTokenFamilyID() (string, error) {
+	return generateTokenID()
+}
+
+// IssueRefreshToken 签发刷新令牌，仅将哈希值写入数据库，明文返回给客户端
+func IssueRefreshToken(userID uint, familyID, userAgent, ip string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	record := models.RefreshToken{
+		UserID:        userID,
+		FamilyID:      familyID,
+		TokenHash:     hashToken(token),
+		UserAgentHash: hashToken(userAgent),
+		IP:            ip,
+		ExpiresAt:     time.Now().Add(RefreshTokenTTL),
+	}
+	if err := database.DB.Create(&record).Error; err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RotateRefreshToken 校验并消费刷新令牌，返回其记录以便签发新令牌。
+// 已消费或已吊销的令牌再次出现时视为重放，整个家族将被吊销。
+func RotateRefreshToken(token, userAgent string) (*models.RefreshToken, error) {
+	if token == "" {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	var record models.RefreshToken
+	if err := database.DB.Where("token_hash = ?", hashToken(token)).First(&record).Error; err != nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+
+	if record.UsedAt != nil || record.RevokedAt != nil {
+		RevokeTokenFamily(record.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
+	if time.Now().After(record.ExpiresAt) {
+		return nil, ErrRefreshTokenExpired
+	}
+	if record.UserAgentHash != hashToken(userAgent) {
+		RevokeTokenFamily(record.FamilyID)
+		return nil, ErrRefreshTokenMismatch
+	}
+
+	// 条件更新保证并发请求中只有一个能消费成功
+	now := time.Now()
+	result := database.DB.Model(&models.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", record.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		RevokeTokenFamily(record.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	record.UsedAt = &now
+	return &record, nil
+}
+
+func revokeRefreshTokenFamily(familyID string) error {
+	return database.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func revokeUserRefreshTokens(userID uint) error {
+	return database.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func hashToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
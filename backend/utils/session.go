@@ -0,0 +1,204 @@
+package utils
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"haodun_manage/backend/database"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sessionInfoPrefix = "session:info:"
+	sessionUserPrefix = "session:user:"
+
+	// sessionTouchInterval 最近活跃时间的最小更新间隔，避免每个请求都写 Redis
+	sessionTouchInterval = time.Minute
+)
+
+// Session 登录会话，ID 与令牌家族ID一致
+type Session struct {
+	ID         string    `json:"id"`
+	UserID     uint      `json:"user_id"`
+	Username   string    `json:"username"`
+	IP         string    `json:"ip"`
+	Country    string    `json:"country"`
+	Province   string    `json:"province"`
+	City       string    `json:"city"`
+	ISP        string    `json:"isp"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+// touchSessionScript 仅在会话仍存在时更新最近活跃时间，避免已终止的会话被重新写入
+var touchSessionScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	local last = tonumber(redis.call("HGET", KEYS[1], "last_seen_at") or "0")
+	if tonumber(ARGV[1]) - last >= tonumber(ARGV[2]) then
+		redis.call("HSET", KEYS[1], "last_seen_at", ARGV[1], "ip", ARGV[3])
+	end
+	return 1
+end
+return 0
+`)
+
+func sessionUserKey(userID uint) string {
+	return sessionUserPrefix + strconv.FormatUint(uint64(userID), 10)
+}
+
+// CreateSession 登录成功后登记会话，地理位置异步补全
+func CreateSession(sessionID string, userID uint, username, ip, userAgent string) error {
+	if sessionID == "" {
+		return nil
+	}
+	ctx := context.Background()
+	now := time.Now().Unix()
+	key := sessionInfoPrefix + sessionID
+
+	pipe := database.RedisClient.TxPipeline()
+	pipe.HSet(ctx, key, map[string]interface{}{
+		"user_id":      userID,
+		"username":     username,
+		"ip":           ip,
+		"user_agent":   userAgent,
+		"created_at":   now,
+		"last_seen_at": now,
+	})
+	pipe.Expire(ctx, key, RefreshTokenTTL)
+	pipe.SAdd(ctx, sessionUserKey(userID), sessionID)
+	pipe.Expire(ctx, sessionUserKey(userID), RefreshTokenTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	go func() {
+		country, province, city, isp := GetIPGeoInfoSafe(ip)
+		database.RedisClient.HSet(context.Background(), key, map[string]interface{}{
+			"country":  country,
+			"province": province,
+			"city":     city,
+			"isp":      isp,
+		})
+	}()
+	return nil
+}
+
+// TouchSession 更新会话最近活跃时间与IP
+func TouchSession(sessionID, ip string) {
+	if sessionID == "" {
+		return
+	}
+	touchSessionScript.Run(context.Background(), database.RedisClient,
+		[]string{sessionInfoPrefix + sessionID},
+		time.Now().Unix(), int(sessionTouchInterval.Seconds()), ip)
+}
+
+// ExtendSession 刷新令牌轮换时延长会话有效期
+func ExtendSession(sessionID string, userID uint) {
+	if sessionID == "" {
+		return
+	}
+	ctx := context.Background()
+	database.RedisClient.Expire(ctx, sessionInfoPrefix+sessionID, RefreshTokenTTL)
+	database.RedisClient.Expire(ctx, sessionUserKey(userID), RefreshTokenTTL)
+}
+
+// GetSession 读取单个会话
+func GetSession(sessionID string) (*Session, bool) {
+	values, err := database.RedisClient.HGetAll(context.Background(), sessionInfoPrefix+sessionID).Result()
+	if err != nil || len(values) == 0 {
+		return nil, false
+	}
+	return parseSession(sessionID, values), true
+}
+
+// ListUserSessions 列出用户的全部会话，按最近活跃时间倒序
+func ListUserSessions(userID uint) []Session {
+	ctx := context.Background()
+	ids, _ := database.RedisClient.SMembers(ctx, sessionUserKey(userID)).Result()
+
+	sessions := make([]Session, 0, len(ids))
+	for _, id := range ids {
+		session, ok := GetSession(id)
+		if !ok {
+			database.RedisClient.SRem(ctx, sessionUserKey(userID), id)
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+	sortSessions(sessions)
+	return sessions
+}
+
+// ListAllSessions 列出所有用户的会话，userID 不为0时只返回该用户的会话
+func ListAllSessions(userID uint) []Session {
+	if userID != 0 {
+		return ListUserSessions(userID)
+	}
+
+	ctx := context.Background()
+	sessions := make([]Session, 0)
+	iter := database.RedisClient.Scan(ctx, 0, sessionInfoPrefix+"*", 200).Iterator()
+	for iter.Next(ctx) {
+		id := iter.Val()[len(sessionInfoPrefix):]
+		if session, ok := GetSession(id); ok {
+			sessions = append(sessions, *session)
+		}
+	}
+	sortSessions(sessions)
+	return sessions
+}
+
+// DeleteSession 删除会话记录
+func DeleteSession(sessionID string) {
+	if sessionID == "" {
+		return
+	}
+	ctx := context.Background()
+	if session, ok := GetSession(sessionID); ok {
+		database.RedisClient.SRem(ctx, sessionUserKey(session.UserID), sessionID)
+	}
+	database.RedisClient.Del(ctx, sessionInfoPrefix+sessionID)
+}
+
+// deleteUserSessions 删除用户的全部会话记录
+func deleteUserSessions(userID uint) {
+	ctx := context.Background()
+	ids, _ := database.RedisClient.SMembers(ctx, sessionUserKey(userID)).Result()
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, sessionInfoPrefix+id)
+	}
+	keys = append(keys, sessionUserKey(userID))
+	database.RedisClient.Del(ctx, keys...)
+}
+
+func parseSession(sessionID string, values map[string]string) *Session {
+	userID, _ := strconv.ParseUint(values["user_id"], 10, 64)
+	createdAt, _ := strconv.ParseInt(values["created_at"], 10, 64)
+	lastSeenAt, _ := strconv.ParseInt(values["last_seen_at"], 10, 64)
+	return &Session{
+		ID:         sessionID,
+		UserID:     uint(userID),
+		Username:   values["username"],
+		IP:         values["ip"],
+		Country:    values["country"],
+		Province:   values["province"],
+		City:       values["city"],
+		ISP:        values["isp"],
+		UserAgent:  values["user_agent"],
+		CreatedAt:  time.Unix(createdAt, 0),
+		LastSeenAt: time.Unix(lastSeenAt, 0),
+	}
+}
+
+func sortSessions(sessions []Session) {
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+// GetConfigString 读取启用状态的系统参数，不存在或为空时返回默认值
+func GetConfigString(key, defaultValue string) string {
+	if database.DB == nil {
+		return defaultValue
+	}
+	var cfg models.Config
+	if err := database.DB.Select("value").Where("`key` = ? AND status = 1", key).First(&cfg).Error; err != nil {
+		return defaultValue
+	}
+	if value := strings.TrimSpace(cfg.Value); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// GetConfigInt 读取整数类型的系统参数，无法解析时返回默认值
+func GetConfigInt(key string, defaultValue int) int {
+	value := GetConfigString(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	if v, err := strconv.Atoi(value); err == nil {
+		return v
+	}
+	return defaultValue
+}
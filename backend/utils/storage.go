@@ -121,6 +121,22 @@ func DeleteAttachment(ctx context.Context, storage string, objectKey string) err
 	}
 }
 
+// OpenAttachment 打开存储中的文件用于读取，调用方负责关闭
+func OpenAttachment(ctx context.Context, storage string, objectKey string) (io.ReadCloser, error) {
+	switch NormalizeStorageDriver(storage) {
+	case StorageDriverCOS:
+		return DownloadFromCOS(ctx, objectKey)
+	case StorageDriverLocal:
+		fullPath, err := getLocalFullPath(objectKey)
+		if err != nil {
+			return nil, err
+		}
+		return os.Open(fullPath)
+	default:
+		return nil, fmt.Errorf("unsupported storage driver: %s", storage)
+	}
+}
+
 func BuildAttachmentURL(storage, objectKey string) (string, error) {
 	switch NormalizeStorageDriver(storage) {
 	case StorageDriverCOS:
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"haodun_manage/backend/database"
+	"haodun_manage/backend/models"
+)
+
+const (
+	revokedTokenPrefix  = "token:revoked:"
+	revokedFamilyPrefix = "token:family_revoked:"
+)
+
+// RevokeToken 将令牌加入黑名单，保留到令牌自然过期为止
+func RevokeToken(claims *Claims) error {
+	if claims == nil || claims.ID == "" {
+		return fmt.Errorf("令牌缺少ID")
+	}
+
+	ttl := AccessTokenTTL
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	return database.RedisClient.Set(context.Background(), revokedTokenPrefix+claims.ID, 1, ttl).Err()
+}
+
+// RevokeUserTokens 使用户在此刻之前签发的所有令牌失效（禁用、改角色、改密码时调用）：
+// 吊销该用户全部刷新令牌，并将其仍有效的令牌家族加入黑名单，之后重新登录签发的令牌不受影响
+func RevokeUserTokens(userID uint) error {
+	if userID == 0 {
+		return nil
+	}
+	var familyIDs []string
+	if err := database.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Distinct().Pluck("family_id", &familyIDs).Error; err != nil {
+		return err
+	}
+	if err := revokeUserRefreshTokens(userID); err != nil {
+		return err
+	}
+	deleteUserSessions(userID)
+
+	ctx := context.Background()
+	pipe := database.RedisClient.Pipeline()
+	for _, familyID := range familyIDs {
+		pipe.Set(ctx, revokedFamilyPrefix+familyID, 1, AccessTokenTTL)
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// RevokeTokenFamily 吊销整个令牌家族（即一个登录会话）：刷新令牌全部作废，已签发的访问令牌立即失效
+func RevokeTokenFamily(familyID string) error {
+	if familyID == "" {
+		return nil
+	}
+	if err := revokeRefreshTokenFamily(familyID); err != nil {
+		return err
+	}
+	DeleteSession(familyID)
+	return database.RedisClient.Set(context.Background(), revokedFamilyPrefix+familyID, 1, AccessTokenTTL).Err()
+}
+
+// IsTokenRevoked 检查令牌是否已被单独吊销，或所属家族（登录会话）已被吊销
+func IsTokenRevoked(claims *Claims) bool {
+	ctx := context.Background()
+
+	if claims.ID != "" {
+		exists, err := database.RedisClient.Exists(ctx, revokedTokenPrefix+claims.ID).Result()
+		if err == nil && exists > 0 {
+			return true
+		}
+	}
+
+	if claims.FamilyID != "" {
+		exists, err := database.RedisClient.Exists(ctx, revokedFamilyPrefix+claims.FamilyID).Result()
+		if err == nil && exists > 0 {
+			return true
+		}
+	}
+	return false
+}
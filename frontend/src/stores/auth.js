@@ -3,6 +3,7 @@ import { jwtDecode } from 'jwt-decode'
 import api from '@/utils/api'
 
 const TOKEN_KEY = 'token'
+const REFRESH_TOKEN_KEY = 'refresh_token'
 const USER_KEY = 'user'
 
 export const useAuthStore = defineStore('auth', {
@@ -18,7 +19,7 @@ export const useAuthStore = defineStore('auth', {
   },
 
   actions: {
-    setAuthData(token, user, permissions = []) {
+    setAuthData(token, user, permissions = [], refreshToken) {
       this.token = token
       this.user = user
       this.permissions = permissions
@@ -31,6 +32,12 @@ export const useAuthStore = defineStore('auth', {
         delete api.defaults.headers.common['Authorization']
       }
 
+      if (refreshToken) {
+        localStorage.setItem(REFRESH_TOKEN_KEY, refreshToken)
+      } else if (!token) {
+        localStorage.removeItem(REFRESH_TOKEN_KEY)
+      }
+
       if (user) {
         localStorage.setItem(USER_KEY, JSON.stringify(user))
       } else {
@@ -38,21 +45,61 @@ export const useAuthStore = defineStore('auth', {
       }
     },
 
-    async login(username, password) {
+    async login(username, password, captcha = {}) {
       try {
-        const response = await api.post('/auth/login', { username, password })
-        const { token, user } = response.data
-        const permissions = Array.isArray(user?.permissions) ? user.permissions : []
-
-        this.setAuthData(token, user, permissions)
-        return { success: true }
+        const response = await api.post('/auth/login', {
+          username,
+          password,
+          captcha_id: captcha.captchaId || '',
+          captcha_code: captcha.captchaCode || ''
+        })
+        if (response.data?.mfa_required) {
+          return {
+            success: false,
+            mfaRequired: true,
+            mfaSetupRequired: !!response.data.mfa_setup_required,
+            mfaToken: response.data.mfa_token
+          }
+        }
+        return this.applyLoginResponse(response.data)
       } catch (error) {
         console.error('Login failed:', error)
-        return { success: false, error: error.response?.data?.error || '登录失败' }
+        return {
+          success: false,
+          captchaRequired: !!error.response?.data?.captcha_required,
+          error: error.response?.data?.error || '登录失败'
+        }
+      }
+    },
+
+    async verifyMfa(mfaToken, code) {
+      try {
+        const response = await api.post('/auth/mfa/verify', { mfa_token: mfaToken, code })
+        return this.applyLoginResponse(response.data)
+      } catch (error) {
+        console.error('MFA verify failed:', error)
+        return { success: false, error: error.response?.data?.error || '二次验证失败' }
+      }
+    },
+
+    applyLoginResponse(data) {
+      const { token, refresh_token: refreshToken, user } = data
+      const permissions = Array.isArray(user?.permissions) ? user.permissions : []
+
+      this.setAuthData(token, user, permissions, refreshToken)
+      return {
+        success: true,
+        recoveryCodes: data.recovery_codes || [],
+        mustChangePassword: !!data.must_change_password
       }
     },
 
     logout() {
+      if (this.token) {
+        api.post('/auth/logout', null, {
+          headers: { Authorization: `Bearer ${this.token}` }
+        }).catch(() => {})
+      }
       this.setAuthData(null, null, [])
     },
 
@@ -88,6 +135,7 @@ export const useAuthStore = defineStore('auth', {
         const data = response.data
         const permissions = Array.isArray(data?.permissions) ? data.permissions : []
 
+        this.token = localStorage.getItem(TOKEN_KEY) || this.token
         this.setAuthData(this.token, data, permissions)
         return true
       } catch (error) {
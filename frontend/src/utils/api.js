@@ -19,20 +19,55 @@ api.interceptors.request.use(
     }
 )
 
+let refreshPromise = null
+
+// 使用刷新令牌换取新的访问令牌，并发请求共用同一次刷新
+const refreshAccessToken = () => {
+    if (!refreshPromise) {
+        const refreshToken = localStorage.getItem('refresh_token')
+        refreshPromise = (refreshToken
+            ? axios.post('/api/auth/refresh', { refresh_token: refreshToken })
+            : Promise.reject(new Error('no refresh token')))
+            .then(response => {
+                const { token, refresh_token } = response.data
+                localStorage.setItem('token', token)
+                localStorage.setItem('refresh_token', refresh_token)
+                api.defaults.headers.common['Authorization'] = `Bearer ${token}`
+                return token
+            })
+            .finally(() => {
+                refreshPromise = null
+            })
+    }
+    return refreshPromise
+}
+
 // 响应拦截器
 api.interceptors.response.use(
     response => {
         return response
     },
-    error => {
+    async error => {
         const status = error.response?.status
         const requestUrl = error.config?.url
 
         if (status === 401) {
-            const isLoginRequest = requestUrl === '/auth/login'
+            const isAuthRequest = requestUrl === '/auth/login' || requestUrl === '/auth/refresh'
 
-            if (!isLoginRequest) {
+            if (!isAuthRequest && !error.config._retried) {
+                try {
+                    const token = await refreshAccessToken()
+                    error.config._retried = true
+                    error.config.headers.Authorization = `Bearer ${token}`
+                    return api(error.config)
+                } catch (refreshError) {
+                    // 刷新失败，回到登录页
+                }
+            }
+
+            if (!isAuthRequest) {
                 localStorage.removeItem('token')
+                localStorage.removeItem('refresh_token')
                 localStorage.removeItem('user')
                 if (window.location.pathname !== '/login') {
                     window.location.href = '/login'
@@ -45,4 +80,3 @@ api.interceptors.response.use(
 )
 
 export default api
-